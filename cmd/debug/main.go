@@ -4,24 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 
-	"github.com/cruxstack/cognito-custom-message-sender-go/internal/aws"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/logging"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/policytest"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/sender"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
 	"github.com/joho/godotenv"
 )
 
 var (
-	dataPath   string
-	policyPath string
+	dataPath      string
+	policyPath    string
+	smsPolicyPath string
+	casesPath     string
 )
 
 func init() {
 	flag.StringVar(&dataPath, "data", "", "path to JSON file with test event data")
 	flag.StringVar(&policyPath, "policy", "", "override path to Rego policy file")
+	flag.StringVar(&smsPolicyPath, "sms-policy", "", "override path to sms Rego policy file")
+	flag.StringVar(&casesPath, "cases", "", "path to JSON file with policy test cases (policy test mode)")
 	flag.Parse()
 }
 
@@ -48,6 +55,12 @@ func NewDebugConfig() (*config.Config, error) {
 	if policyPath != "" {
 		os.Setenv("APP_EMAIL_SENDER_POLICY_PATH", policyPath)
 	}
+	if os.Getenv("APP_SMS_SENDER_POLICY_PATH") == "" {
+		os.Setenv("APP_SMS_SENDER_POLICY_PATH", filepath.Join("..", "..", "fixtures", "debug-sms-policy.rego"))
+	}
+	if smsPolicyPath != "" {
+		os.Setenv("APP_SMS_SENDER_POLICY_PATH", smsPolicyPath)
+	}
 
 	cfg, err := config.New()
 	if err != nil {
@@ -72,7 +85,19 @@ func main() {
 	}
 
 	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.AppLogLevel})
-	slog.SetDefault(slog.New(handler))
+	slog.SetDefault(slog.New(logging.NewHandler(handler, cfg)))
+
+	if args := flag.Args(); len(args) == 2 && args[0] == "policy" && args[1] == "test" {
+		os.Exit(runPolicyTests(cfg))
+	}
+
+	// APP_TRACING_EXPORTER=stdout prints the spans of each event
+	tp, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
+	defer tp.Shutdown(context.Background())
 
 	s, err := sender.NewSender(context.Background(), cfg)
 	if err != nil {
@@ -86,14 +111,14 @@ func main() {
 		os.Exit(1)
 	}
 
-	events := []aws.CognitoEventUserPoolsCustomEmailSender{}
+	events := []json.RawMessage{}
 	if err := json.Unmarshal(data, &events); err != nil {
 		slog.Error("failed to parse event file", "error", err)
 		os.Exit(1)
 	}
 
 	for i, e := range events {
-		if err := s.SendEmail(context.Background(), e); err != nil {
+		if err := s.HandleEvent(context.Background(), e); err != nil {
 			slog.Error("integration test failed", "error", err)
 			os.Exit(1)
 		}
@@ -102,3 +127,58 @@ func main() {
 
 	slog.Info("integration test passed")
 }
+
+// runPolicyTests evaluates the policy test cases without KMS or providers and
+// returns the process exit code.
+func runPolicyTests(cfg *config.Config) int {
+	ctx := context.Background()
+
+	path := casesPath
+	if path == "" {
+		path = filepath.Join("..", "..", "fixtures", "policy-tests.json")
+	}
+
+	cases, err := policytest.ReadCases(path)
+	if err != nil {
+		slog.Error("failed to load policy test cases", "path", path, "error", err)
+		return 1
+	}
+
+	emailPolicy, err := sender.LoadEmailPolicy(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to load email policy", "error", err)
+		return 1
+	}
+	smsPolicy, err := sender.LoadSMSPolicy(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to load sms policy", "error", err)
+		return 1
+	}
+
+	runner := &policytest.Runner{EmailPolicy: emailPolicy, SMSPolicy: smsPolicy}
+	results := runner.Run(ctx, cases)
+
+	failed := 0
+	for _, r := range results {
+		if r.Passed() {
+			fmt.Printf("PASS: %s\n", r.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL: %s\n", r.Name)
+		if r.Err != nil {
+			fmt.Printf("  error: %v\n", r.Err)
+		}
+		for _, d := range r.Diffs {
+			fmt.Printf("  %s\n", d)
+		}
+	}
+
+	fmt.Printf("--------------------------------------------------------------------------------\n")
+	fmt.Printf("PASS: %d/%d\n", len(results)-failed, len(results))
+	if failed > 0 {
+		fmt.Printf("FAIL: %d/%d\n", failed, len(results))
+		return 1
+	}
+	return 0
+}
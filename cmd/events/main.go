@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/feedback"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/logging"
+)
+
+var (
+	h *feedback.Handler
+)
+
+// Handler accepts SNS events with SES notifications and function URL requests
+// with SendGrid event webhooks.
+func Handler(ctx context.Context, event json.RawMessage) (any, error) {
+	if os.Getenv("APP_DEBUG_MODE") == "true" {
+		slog.DebugContext(ctx, "received event", "event", string(event))
+	}
+
+	res, err := h.HandleEvent(ctx, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to handle feedback event", "error", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func main() {
+	cfg, err := config.NewEvents()
+	if err != nil {
+		slog.Error("configuration error", "error", err)
+		os.Exit(1)
+	}
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.AppLogLevel})
+	slog.SetDefault(slog.New(logging.NewHandler(handler, cfg)))
+
+	h, err = feedback.NewHandler(cfg)
+	if err != nil {
+		slog.Error("failed to initialize feedback handler", "error", err)
+		os.Exit(1)
+	}
+
+	lambda.Start(Handler)
+}
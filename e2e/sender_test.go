@@ -6,14 +6,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/opa"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/providers"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/sender"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/verifier"
@@ -32,9 +35,9 @@ func (p *MockProvider) Name() string {
 	return "mock"
 }
 
-func (p *MockProvider) Send(ctx context.Context, d *types.EmailData) error {
+func (p *MockProvider) Send(ctx context.Context, d *types.EmailData) (*providers.Receipt, error) {
 	if p.SendError != nil {
-		return p.SendError
+		return nil, p.SendError
 	}
 
 	// Simulate what the real providers do: merge verification code into template data
@@ -62,7 +65,7 @@ func (p *MockProvider) Send(ctx context.Context, d *types.EmailData) error {
 		emailCopy.Providers = &providersCopy
 	}
 	p.SentEmails = append(p.SentEmails, &emailCopy)
-	return nil
+	return &providers.Receipt{Provider: p.Name(), AcceptedAt: time.Now(), Attempts: 1}, nil
 }
 
 func (p *MockProvider) GetSentEmails() []*types.EmailData {
@@ -420,6 +423,46 @@ func TestSendEmail_WithEmailVerification_Invalid_Denied(t *testing.T) {
 	}
 }
 
+func TestSendEmail_VerificationError_FailureModes(t *testing.T) {
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	testCases := []struct {
+		name          string
+		failureMode   string
+		expectError   bool
+		expectedSends int
+	}{
+		{name: "default passes no result", failureMode: "", expectError: false, expectedSends: 1},
+		{name: "open", failureMode: "open", expectError: false, expectedSends: 1},
+		{name: "closed", failureMode: "closed", expectError: true, expectedSends: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(t, unavailable.URL, true)
+			cfg.AppEmailVerificationFailureMode = tc.failureMode
+			provider := &MockProvider{}
+			s := createTestSender(t, cfg, provider)
+
+			event := newCognitoEvent("CustomEmailSender_SignUp", "xxxx1111", "user@example.com", "123456")
+			err := s.SendEmail(context.Background(), event)
+
+			if tc.expectError && err == nil {
+				t.Fatal("expected verification error to fail the invocation")
+			}
+			if !tc.expectError && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if n := len(provider.GetSentEmails()); n != tc.expectedSends {
+				t.Errorf("expected %d emails sent, got %d", tc.expectedSends, n)
+			}
+		})
+	}
+}
+
 func TestSendEmail_WhitelistedDomain_SkipsAPIVerification(t *testing.T) {
 	mockSendGrid := NewSendGridMockServer()
 	defer mockSendGrid.Close()
@@ -574,6 +617,48 @@ func TestSendEmail_TemplateDataContainsClientID(t *testing.T) {
 	}
 }
 
+func TestParseEmailData_ProviderOrder(t *testing.T) {
+	testCases := []struct {
+		name        string
+		provider    string
+		sendGridKey string
+		order       []string
+		expectError string
+	}{
+		{name: "configured provider", provider: "ses", order: []string{"ses"}},
+		{name: "configured standby", provider: "ses", sendGridKey: "key", order: []string{"sendgrid", "ses"}},
+		{name: "unconfigured ses", provider: "sendgrid", sendGridKey: "key", order: []string{"ses"}, expectError: "not configured: ses"},
+		{name: "unconfigured sendgrid", provider: "ses", order: []string{"ses", "sendgrid"}, expectError: "not configured: sendgrid"},
+		{name: "unknown provider", provider: "ses", order: []string{"postmark"}, expectError: "unknown provider"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(t, "", false)
+			cfg.AppEmailProvider = tc.provider
+			cfg.SendGridEmailSendApiKey = tc.sendGridKey
+			s := createTestSender(t, cfg, &MockProvider{})
+
+			_, err := s.ParseEmailData(&types.EmailData{
+				SourceAddress:      "noreply@example.com",
+				DestinationAddress: "user@example.com",
+				ProviderOrder:      tc.order,
+				Providers: &types.EmailProviderMap{
+					SES:      &types.EmailProviderData{TemplateID: "welcome"},
+					SendGrid: &types.EmailProviderData{TemplateID: "d-123"},
+				},
+			})
+
+			if tc.expectError == "" && err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if tc.expectError != "" && (err == nil || !strings.Contains(err.Error(), tc.expectError)) {
+				t.Fatalf("expected error containing %q, got: %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
 // TestMain sets up the test environment
 func TestMain(m *testing.M) {
 	// Set required environment for AWS SDK
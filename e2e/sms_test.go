@@ -0,0 +1,178 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/opa"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/sender"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+
+	awsinternal "github.com/cruxstack/cognito-custom-message-sender-go/internal/aws"
+)
+
+// MockSMSProvider captures sent sms messages for verification in tests
+type MockSMSProvider struct {
+	mu      sync.Mutex
+	SentSMS []*types.SMSData
+}
+
+func (p *MockSMSProvider) Name() string {
+	return "mock"
+}
+
+func (p *MockSMSProvider) SendSMS(ctx context.Context, d *types.SMSData) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	smsCopy := *d
+	p.SentSMS = append(p.SentSMS, &smsCopy)
+	return nil
+}
+
+func (p *MockSMSProvider) GetSentSMS() []*types.SMSData {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.SentSMS
+}
+
+// createTestSMSSender creates a Sender with both email and sms paths mocked
+func createTestSMSSender(t *testing.T, cfg *config.Config, provider *MockProvider, smsProvider *MockSMSProvider) *sender.Sender {
+	t.Helper()
+
+	s := createTestSender(t, cfg, provider)
+
+	policy, err := opa.ReadPolicy("../fixtures/debug-sms-policy.rego")
+	if err != nil {
+		t.Fatalf("failed to read sms policy: %v", err)
+	}
+
+	s.SMSPolicy, err = opa.PreparePolicy(context.Background(), policy, "data.cognito_custom_sender_sms_policy.result")
+	if err != nil {
+		t.Fatalf("failed to prepare sms policy: %v", err)
+	}
+	s.SMSProvider = smsProvider
+
+	return s
+}
+
+// newCognitoSMSEvent creates a test Cognito sms event
+func newCognitoSMSEvent(trigger, clientID, phone, code string) awsinternal.CognitoEventUserPoolsCustomSMSSender {
+	attrs := map[string]any{
+		"phone_number_verified": "false",
+		"sub":                   "00000000-0000-0000-0000-000000000000",
+	}
+	if phone != "" {
+		attrs["phone_number"] = phone
+	}
+
+	return awsinternal.CognitoEventUserPoolsCustomSMSSender{
+		CognitoEventUserPoolsHeader: events.CognitoEventUserPoolsHeader{
+			Version:       "1",
+			TriggerSource: trigger,
+			Region:        "us-east-1",
+			UserPoolID:    "us-east-1_test12345",
+			CallerContext: events.CognitoEventUserPoolsCallerContext{
+				AWSSDKVersion: "aws-sdk-unknown-unknown",
+				ClientID:      clientID,
+			},
+			UserName: phone,
+		},
+		Request: awsinternal.CognitoEventUserPoolsCustomSMSSenderRequest{
+			UserAttributes: attrs,
+			Code:           code,
+			Type:           "customSMSSenderRequestV1",
+		},
+	}
+}
+
+func TestSendSMS_PolicyAllows_SMSSent(t *testing.T) {
+	cfg := testConfig(t, "", false)
+	smsProvider := &MockSMSProvider{}
+	s := createTestSMSSender(t, cfg, &MockProvider{}, smsProvider)
+
+	event := newCognitoSMSEvent("CustomSMSSender_SignUp", "xxxx1111", "+15555550100", "123456")
+
+	if err := s.SendSMS(context.Background(), event); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	sent := smsProvider.GetSentSMS()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 sms sent, got %d", len(sent))
+	}
+	if sent[0].DestinationPhoneNumber != "+15555550100" {
+		t.Errorf("expected destination '+15555550100', got '%s'", sent[0].DestinationPhoneNumber)
+	}
+	if sent[0].SenderID != "ACME" {
+		t.Errorf("expected sender id 'ACME', got '%s'", sent[0].SenderID)
+	}
+	if sent[0].VerificationCode != "123456" {
+		t.Errorf("expected code '123456', got '%s'", sent[0].VerificationCode)
+	}
+}
+
+func TestSendSMS_PolicyDenies_NoSMSSent(t *testing.T) {
+	cfg := testConfig(t, "", false)
+	smsProvider := &MockSMSProvider{}
+	s := createTestSMSSender(t, cfg, &MockProvider{}, smsProvider)
+
+	event := newCognitoSMSEvent("CustomSMSSender_SignUp", "xxxx1111", "", "123456")
+
+	if err := s.SendSMS(context.Background(), event); err != nil {
+		t.Fatalf("expected no error (policy denial is not an error), got: %v", err)
+	}
+	if len(smsProvider.GetSentSMS()) != 0 {
+		t.Fatalf("expected 0 sms sent (policy denied), got %d", len(smsProvider.GetSentSMS()))
+	}
+}
+
+func TestSendSMS_NotConfigured_Error(t *testing.T) {
+	cfg := testConfig(t, "", false)
+	s := createTestSender(t, cfg, &MockProvider{})
+
+	event := newCognitoSMSEvent("CustomSMSSender_SignUp", "xxxx1111", "+15555550100", "123456")
+
+	if err := s.SendSMS(context.Background(), event); err == nil {
+		t.Fatal("expected error when sms is not configured, got nil")
+	}
+}
+
+func TestHandleEvent_RoutesByTriggerSource(t *testing.T) {
+	cfg := testConfig(t, "", false)
+	provider := &MockProvider{}
+	smsProvider := &MockSMSProvider{}
+	s := createTestSMSSender(t, cfg, provider, smsProvider)
+
+	ctx := context.Background()
+
+	emailRaw, err := json.Marshal(newCognitoEvent("CustomEmailSender_SignUp", "xxxx1111", "user@example.com", "111111"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	smsRaw, err := json.Marshal(newCognitoSMSEvent("CustomSMSSender_ForgotPassword", "xxxx2222", "+15555550100", "222222"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.HandleEvent(ctx, emailRaw); err != nil {
+		t.Fatalf("expected no error for email event, got: %v", err)
+	}
+	if err := s.HandleEvent(ctx, smsRaw); err != nil {
+		t.Fatalf("expected no error for sms event, got: %v", err)
+	}
+
+	if len(provider.GetSentEmails()) != 1 {
+		t.Errorf("expected 1 email sent, got %d", len(provider.GetSentEmails()))
+	}
+	sent := smsProvider.GetSentSMS()
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 sms sent, got %d", len(sent))
+	}
+	if sent[0].SenderID != "ACMEPRO" {
+		t.Errorf("expected sender id 'ACMEPRO', got '%s'", sent[0].SenderID)
+	}
+}
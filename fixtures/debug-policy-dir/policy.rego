@@ -0,0 +1,46 @@
+package cognito_custom_sender_email_policy
+
+import rego.v1
+
+# client-id to template-id mappings are loaded from data.yaml
+template_id = id if {
+  id := data.template_map[input.callerContext.clientId]
+}
+
+# fallback template-id if client-id is missing from `template_map`
+template_id = "default-template" if {
+  not data.template_map[input.callerContext.clientId]
+}
+
+result := deny_result if {
+  input.emailVerification != null
+  input.emailVerification.valid == false
+}
+
+result := allow_result if {
+  not deny_result
+}
+
+allow_result := {
+  "action": "allow",
+  "allow": {
+    "srcAddress": data.sender.srcAddress,
+    "dstAddress": input.userAttributes.email,
+    "providers": {
+      "ses": {
+        "templateId": template_id,
+        "templateData": {
+          "clientId": input.callerContext.clientId
+        }
+      }
+    }
+  }
+}
+
+deny_result := {
+  "action": "deny",
+  "reason": "email verification failed"
+} if {
+  input.emailVerification != null
+  input.emailVerification.valid == false
+}
@@ -0,0 +1,40 @@
+package cognito_custom_sender_sms_policy
+
+import rego.v1
+
+# map between client-id to sender-id
+sender_map := {
+  "xxxx1111": "ACME",
+  "xxxx2222": "ACMEPRO",
+}
+
+sender_id = id if {
+  id := sender_map[input.callerContext.clientId]
+}
+
+# fallback sender-id if client-id is missing from `sender_map`
+sender_id = "ACMEDEFAULT" if {
+  not sender_map[input.callerContext.clientId]
+}
+
+result := deny_result if {
+  not input.userAttributes.phone_number
+}
+
+result := allow_result if {
+  input.userAttributes.phone_number
+}
+
+allow_result := {
+  "action": "allow",
+  "allow": {
+    "dstPhoneNumber": input.userAttributes.phone_number,
+    "senderId": sender_id,
+    "message": "Your ACME verification code is {####}"
+  }
+}
+
+deny_result := {
+  "action": "deny",
+  "reason": "phone number missing"
+}
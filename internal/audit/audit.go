@@ -0,0 +1,256 @@
+// Package audit records an immutable trail of every send attempt so support
+// can tell whether a user was sent their message.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/google/uuid"
+)
+
+// Outcomes of an invocation and of a single provider attempt.
+const (
+	OutcomeSent      = "sent"
+	OutcomeFailed    = "failed"
+	OutcomeDenied    = "denied"
+	OutcomeDuplicate = "duplicate"
+)
+
+// Sink stores audit records. Records are never updated once written.
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, r *Record) error
+}
+
+// Record is the audit record of one invocation. It never contains the
+// verification code or the recipient's addresses; the user is identified by
+// their sub. Provider errors are recorded by class and status code only, since
+// their text can echo the recipient's address.
+type Record struct {
+	ID            string    `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	CompletedAt   time.Time `json:"completedAt"`
+	Channel       string    `json:"channel"`
+	UserPoolID    string    `json:"userPoolId"`
+	UserSub       string    `json:"userSub,omitempty"`
+	TriggerSource string    `json:"triggerSource"`
+	ClientID      string    `json:"clientId,omitempty"`
+	Decision      string    `json:"decision,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	Verification  string    `json:"verification,omitempty"`
+	Attempts      []Attempt `json:"attempts"`
+	Provider      string    `json:"provider,omitempty"`
+	MessageID     string    `json:"messageId,omitempty"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+
+	mu sync.Mutex
+}
+
+// Attempt is a single provider send attempt. Retries and failover hops each
+// add an attempt.
+type Attempt struct {
+	Provider   string    `json:"provider"`
+	Outcome    string    `json:"outcome"`
+	ErrorClass string    `json:"errorClass,omitempty"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	MessageID  string    `json:"messageId,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// NewRecord starts the audit record of an invocation.
+func NewRecord(channel, userPoolID, sub, trigger, clientID string) *Record {
+	return &Record{
+		ID:            uuid.NewString(),
+		Timestamp:     time.Now().UTC(),
+		Channel:       channel,
+		UserPoolID:    userPoolID,
+		UserSub:       sub,
+		TriggerSource: trigger,
+		ClientID:      clientID,
+		Attempts:      []Attempt{},
+	}
+}
+
+// SetDecision records the policy decision and its reason. It is a no-op on a
+// nil record.
+func (r *Record) SetDecision(decision, reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Decision = decision
+	r.Reason = reason
+}
+
+// SetVerification records the email verification outcome. It is a no-op on a
+// nil record.
+func (r *Record) SetVerification(outcome string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Verification = outcome
+}
+
+// SetOutcome records an outcome decided before any provider was tried, such
+// as a denial or duplicate. It is a no-op on a nil record.
+func (r *Record) SetOutcome(outcome string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Outcome = outcome
+}
+
+// SetDelivery records the provider that accepted the message and its message
+// ID. It is a no-op on a nil record.
+func (r *Record) SetDelivery(provider, messageID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Provider = provider
+	r.MessageID = messageID
+}
+
+// AddAttempt appends a provider attempt. It is a no-op on a nil record.
+func (r *Record) AddAttempt(a Attempt) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Attempts = append(r.Attempts, a)
+}
+
+// Finish completes the record. Without an explicit outcome, the message
+// counts as sent only if a provider accepted it, since a failover chain may
+// swallow the error of an undelivered message.
+func (r *Record) Finish(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.CompletedAt = time.Now().UTC()
+	if err != nil {
+		r.Outcome = OutcomeFailed
+		r.Error = errorText(err)
+		return
+	}
+	if r.Outcome != "" {
+		return
+	}
+
+	r.Outcome = OutcomeFailed
+	for _, a := range r.Attempts {
+		if a.Outcome == OutcomeSent {
+			r.Outcome = OutcomeSent
+			break
+		}
+	}
+}
+
+// ClassifiedError is implemented by errors whose text may contain message
+// data, such as provider errors. Records store their class instead.
+type ClassifiedError interface {
+	error
+	ErrorClass() string
+}
+
+// errorText returns the text of err to record, or only the class of a
+// classified error in its chain.
+func errorText(err error) string {
+	var ce ClassifiedError
+	if errors.As(err, &ce) {
+		return ce.ErrorClass() + " provider error"
+	}
+	return err.Error()
+}
+
+type recordKey struct{}
+
+// NewContext returns a context carrying r.
+func NewContext(ctx context.Context, r *Record) context.Context {
+	return context.WithValue(ctx, recordKey{}, r)
+}
+
+// FromContext returns the record carried by ctx, or nil.
+func FromContext(ctx context.Context) *Record {
+	r, _ := ctx.Value(recordKey{}).(*Record)
+	return r
+}
+
+// Auditor writes finished records to a sink. Write errors are logged and never
+// fail the send.
+type Auditor struct {
+	Sink Sink
+}
+
+// NewAuditor creates the auditor selected by configuration. It returns nil
+// when auditing is disabled.
+func NewAuditor(cfg *config.Config) (*Auditor, error) {
+	if cfg.AppAuditSink == "" {
+		return nil, nil
+	}
+
+	sink, err := NewSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Auditor{Sink: sink}, nil
+}
+
+// NewRecord starts a record, or returns nil when a is nil so that callers can
+// use the record unconditionally.
+func (a *Auditor) NewRecord(channel, userPoolID, sub, trigger, clientID string) *Record {
+	if a == nil {
+		return nil
+	}
+	return NewRecord(channel, userPoolID, sub, trigger, clientID)
+}
+
+// Write finishes r with err and writes it to the sink.
+func (a *Auditor) Write(ctx context.Context, r *Record, err error) {
+	if a == nil || r == nil {
+		return
+	}
+	r.Finish(err)
+
+	if err := a.Sink.Write(ctx, r); err != nil {
+		slog.ErrorContext(ctx, "failed to write audit record",
+			"sink", a.Sink.Name(),
+			"audit_id", r.ID,
+			"error", err,
+		)
+	}
+}
+
+// NewSink creates the audit sink selected by configuration.
+func NewSink(cfg *config.Config) (Sink, error) {
+	switch cfg.AppAuditSink {
+	case "dynamodb":
+		return NewDynamoDBSink(cfg), nil
+	case "firehose":
+		return NewFirehoseSink(cfg), nil
+	case "kinesis":
+		return NewKinesisSink(cfg), nil
+	case "file":
+		return NewFileSink(cfg.AppAuditTarget), nil
+	default:
+		return nil, fmt.Errorf("unknown audit sink: %s", cfg.AppAuditSink)
+	}
+}
@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+)
+
+type mockDynamoDBClient struct {
+	input *dynamodb.PutItemInput
+}
+
+func (m *mockDynamoDBClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	m.input = input
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+type mockKinesisClient struct {
+	input *kinesis.PutRecordInput
+}
+
+func (m *mockKinesisClient) PutRecord(ctx context.Context, input *kinesis.PutRecordInput, opts ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	m.input = input
+	return &kinesis.PutRecordOutput{}, nil
+}
+
+func newTestRecord() *Record {
+	return NewRecord("email", "us-east-1_abc", "user-sub", "CustomEmailSender_SignUp", "client-id")
+}
+
+func TestRecord_Finish(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(r *Record)
+		err      error
+		expected string
+	}{
+		{
+			name: "sent by a later attempt",
+			setup: func(r *Record) {
+				r.AddAttempt(Attempt{Provider: "ses", Outcome: OutcomeFailed})
+				r.AddAttempt(Attempt{Provider: "sendgrid", Outcome: OutcomeSent})
+			},
+			expected: OutcomeSent,
+		},
+		{
+			name: "swallowed failure",
+			setup: func(r *Record) {
+				r.AddAttempt(Attempt{Provider: "ses", Outcome: OutcomeFailed})
+			},
+			expected: OutcomeFailed,
+		},
+		{
+			name:     "denied",
+			setup:    func(r *Record) { r.SetOutcome(OutcomeDenied) },
+			expected: OutcomeDenied,
+		},
+		{
+			name:     "error",
+			setup:    func(r *Record) {},
+			err:      errors.New("failed to decrypt verification code"),
+			expected: OutcomeFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRecord()
+			tt.setup(r)
+			r.Finish(tt.err)
+
+			if r.Outcome != tt.expected {
+				t.Errorf("expected outcome %q, got %q", tt.expected, r.Outcome)
+			}
+			if r.CompletedAt.IsZero() {
+				t.Error("expected completion time to be set")
+			}
+			if tt.err != nil && r.Error != tt.err.Error() {
+				t.Errorf("expected error %q, got %q", tt.err, r.Error)
+			}
+		})
+	}
+}
+
+func TestAuditor_NilSafe(t *testing.T) {
+	var a *Auditor
+	r := a.NewRecord("email", "pool", "sub", "trigger", "client")
+	if r != nil {
+		t.Fatal("expected nil record without an auditor")
+	}
+
+	// none of these may panic
+	r.SetDecision("allow", "")
+	r.SetVerification("valid")
+	r.AddAttempt(Attempt{Provider: "ses"})
+	a.Write(context.Background(), r, nil)
+}
+
+func TestFileSink_AppendsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	a := &Auditor{Sink: NewFileSink(path)}
+
+	for i := 0; i < 2; i++ {
+		r := newTestRecord()
+		r.SetDecision("allow", "")
+		r.AddAttempt(Attempt{Provider: "ses", Outcome: OutcomeSent, MessageID: "msg-1"})
+		a.Write(context.Background(), r, nil)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("invalid json line: %v", err)
+		}
+		if r.UserSub != "user-sub" || r.Outcome != OutcomeSent || r.Attempts[0].MessageID != "msg-1" {
+			t.Errorf("unexpected record: %+v", &r)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestDynamoDBSink_Write(t *testing.T) {
+	client := &mockDynamoDBClient{}
+	sink := &DynamoDBSink{Client: client, Table: "audit"}
+
+	r := newTestRecord()
+	r.Finish(nil)
+	if err := sink.Write(context.Background(), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *client.input.ConditionExpression != "attribute_not_exists(pk)" {
+		t.Errorf("expected conditional write, got %q", *client.input.ConditionExpression)
+	}
+	pk := client.input.Item["pk"].(*ddbtypes.AttributeValueMemberS).Value
+	if pk != "us-east-1_abc#user-sub" {
+		t.Errorf("unexpected partition key: %s", pk)
+	}
+	sk := client.input.Item["sk"].(*ddbtypes.AttributeValueMemberS).Value
+	if !strings.HasSuffix(sk, "#"+r.ID) {
+		t.Errorf("expected sort key to end with record id, got %s", sk)
+	}
+}
+
+func TestKinesisSink_Write(t *testing.T) {
+	client := &mockKinesisClient{}
+	sink := &KinesisSink{Client: client, StreamName: "audit"}
+
+	if err := sink.Write(context.Background(), newTestRecord()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *client.input.PartitionKey != "us-east-1_abc#user-sub" {
+		t.Errorf("unexpected partition key: %s", *client.input.PartitionKey)
+	}
+	var r Record
+	if err := json.Unmarshal(client.input.Data, &r); err != nil {
+		t.Fatalf("invalid record: %v", err)
+	}
+}
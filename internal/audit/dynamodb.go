@@ -0,0 +1,62 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBSink.
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoDBSink writes audit records to a DynamoDB table with a string
+// partition key "pk" of "<user pool id>#<sub>" and a string sort key "sk" of
+// "<timestamp>#<record id>", so a user's records can be queried in order.
+// Writes are conditional, so an existing record is never overwritten.
+type DynamoDBSink struct {
+	Client DynamoDBAPI
+	Table  string
+}
+
+func NewDynamoDBSink(cfg *config.Config) *DynamoDBSink {
+	return &DynamoDBSink{
+		Client: dynamodb.NewFromConfig(*cfg.AWSConfig),
+		Table:  cfg.AppAuditTarget,
+	}
+}
+
+func (s *DynamoDBSink) Name() string {
+	return "dynamodb"
+}
+
+func (s *DynamoDBSink) Write(ctx context.Context, r *Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error marshaling audit record: %w", err)
+	}
+
+	_, err = s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: awssdk.String(s.Table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"pk":             &ddbtypes.AttributeValueMemberS{Value: r.UserPoolID + "#" + r.UserSub},
+			"sk":             &ddbtypes.AttributeValueMemberS{Value: r.Timestamp.Format(time.RFC3339Nano) + "#" + r.ID},
+			"trigger_source": &ddbtypes.AttributeValueMemberS{Value: r.TriggerSource},
+			"outcome":        &ddbtypes.AttributeValueMemberS{Value: r.Outcome},
+			"record":         &ddbtypes.AttributeValueMemberS{Value: string(body)},
+		},
+		ConditionExpression: awssdk.String("attribute_not_exists(pk)"),
+	})
+	if err != nil {
+		return fmt.Errorf("error writing audit record: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSink appends audit records as JSON lines to a local file. It is intended
+// for local runs and tests.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{Path: path}
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Write(ctx context.Context, r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error marshaling audit record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening audit file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing audit file: %w", err)
+	}
+
+	return nil
+}
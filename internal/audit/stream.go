@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	firehosetypes "github.com/aws/aws-sdk-go-v2/service/firehose/types"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// FirehoseAPI is the subset of the Firehose client used by FirehoseSink.
+type FirehoseAPI interface {
+	PutRecord(ctx context.Context, params *firehose.PutRecordInput, optFns ...func(*firehose.Options)) (*firehose.PutRecordOutput, error)
+}
+
+// FirehoseSink writes audit records as JSON lines to a Firehose delivery
+// stream, e.g. one delivering to an S3 bucket with object lock.
+type FirehoseSink struct {
+	Client     FirehoseAPI
+	StreamName string
+}
+
+func NewFirehoseSink(cfg *config.Config) *FirehoseSink {
+	return &FirehoseSink{
+		Client:     firehose.NewFromConfig(*cfg.AWSConfig),
+		StreamName: cfg.AppAuditTarget,
+	}
+}
+
+func (s *FirehoseSink) Name() string {
+	return "firehose"
+}
+
+func (s *FirehoseSink) Write(ctx context.Context, r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error marshaling audit record: %w", err)
+	}
+
+	_, err = s.Client.PutRecord(ctx, &firehose.PutRecordInput{
+		DeliveryStreamName: awssdk.String(s.StreamName),
+		Record:             &firehosetypes.Record{Data: append(line, '\n')},
+	})
+	if err != nil {
+		return fmt.Errorf("error writing audit record: %w", err)
+	}
+
+	return nil
+}
+
+// KinesisAPI is the subset of the Kinesis client used by KinesisSink.
+type KinesisAPI interface {
+	PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+}
+
+// KinesisSink writes audit records to a Kinesis data stream, partitioned by
+// user so a user's records stay in order.
+type KinesisSink struct {
+	Client     KinesisAPI
+	StreamName string
+}
+
+func NewKinesisSink(cfg *config.Config) *KinesisSink {
+	return &KinesisSink{
+		Client:     kinesis.NewFromConfig(*cfg.AWSConfig),
+		StreamName: cfg.AppAuditTarget,
+	}
+}
+
+func (s *KinesisSink) Name() string {
+	return "kinesis"
+}
+
+func (s *KinesisSink) Write(ctx context.Context, r *Record) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error marshaling audit record: %w", err)
+	}
+
+	_, err = s.Client.PutRecord(ctx, &kinesis.PutRecordInput{
+		StreamName:   awssdk.String(s.StreamName),
+		PartitionKey: awssdk.String(r.UserPoolID + "#" + r.UserSub),
+		Data:         body,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing audit record: %w", err)
+	}
+
+	return nil
+}
@@ -7,16 +7,16 @@ import (
 	"log/slog"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/ses"
-	"github.com/aws/aws-sdk-go-v2/service/ses/types"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2/types"
 )
 
 type SESClient struct {
-	Client *ses.Client
+	Client *sesv2.Client
 }
 
 func NewSESClient(cfg aws.Config) *SESClient {
-	return &SESClient{Client: ses.NewFromConfig(cfg)}
+	return &SESClient{Client: sesv2.NewFromConfig(cfg)}
 }
 
 func (c *SESClient) SendEmail(ctx context.Context, templateID string, templateData map[string]any, srcAddress, dstAddress string, dryRun bool) error {
@@ -35,11 +35,15 @@ func (c *SESClient) SendEmail(ctx context.Context, templateID string, templateDa
 		return nil
 	}
 
-	_, err = c.Client.SendTemplatedEmail(ctx, &ses.SendTemplatedEmailInput{
-		Source:       aws.String(srcAddress),
-		Template:     aws.String(templateID),
-		TemplateData: aws.String(string(templateDataJSON)),
-		Destination:  &types.Destination{ToAddresses: []string{dstAddress}},
+	_, err = c.Client.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: aws.String(srcAddress),
+		Destination:      &types.Destination{ToAddresses: []string{dstAddress}},
+		Content: &types.EmailContent{
+			Template: &types.Template{
+				TemplateName: aws.String(templateID),
+				TemplateData: aws.String(string(templateDataJSON)),
+			},
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("error sending templated email: %w", err)
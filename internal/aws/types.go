@@ -23,3 +23,15 @@ type CognitoEventUserPoolsCustomEmailSenderResponse struct {
 	EmailMessage string `json:"emailMessage"`
 	EmailSubject string `json:"emailSubject"`
 }
+
+type CognitoEventUserPoolsCustomSMSSender struct {
+	events.CognitoEventUserPoolsHeader
+	Request CognitoEventUserPoolsCustomSMSSenderRequest `json:"request"`
+}
+
+type CognitoEventUserPoolsCustomSMSSenderRequest struct {
+	UserAttributes map[string]any    `json:"userAttributes"`
+	Code           string            `json:"code"`
+	ClientMetadata map[string]string `json:"clientMetadata"`
+	Type           string            `json:"type"`
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,12 +17,24 @@ import (
 type Config struct {
 	AWSConfig                       *aws.Config
 	AppLogLevel                     slog.Level
+	AppLogRedactKeys                []string
+	AppLogRedactionDisabled         bool
 	AppKmsKeyId                     string
 	AppEmailProvider                string
 	AppEmailSenderPolicyPath        string
+	AppPolicyRefreshInterval        time.Duration
+	AppPolicyBundleToken            string
+	AppDecisionLogSink              string
+	AppDecisionLogTarget            string
+	AppDecisionLogRedactPaths       []string
+	AppEmailTemplatesPath           string
 	AppEmailVerificationEnabled     bool
 	AppEmailVerificationProvider    string
+	AppEmailVerificationFailureMode string
 	AppEmailVerificationWhitelist   []string
+	AppEmailVerificationDNSResolver string
+	AppEmailVerificationDNSTimeout  time.Duration
+	AppEmailVerificationDNSCacheTTL time.Duration
 	AppSendEnabled                  bool
 	DebugMode                       bool
 	DebugDataPath                   string
@@ -32,9 +46,119 @@ type Config struct {
 	AppEmailFailoverEnabled   bool
 	AppEmailFailoverProviders []string
 	AppEmailFailoverCacheTTL  time.Duration
+
+	// Failover circuit breaker configuration
+	AppEmailFailoverBreakerEnabled           bool
+	AppEmailFailoverBreakerFailureThreshold  int
+	AppEmailFailoverBreakerSlowCallThreshold time.Duration
+	AppEmailFailoverBreakerCooldown          time.Duration
+
+	// Failover all-providers-failed configuration
+	AppEmailFailoverAllFailedAction string
+	AppEmailDeadLetterType          string
+	AppEmailDeadLetterTarget        string
+
+	// Retry configuration
+	AppEmailRetryMaxAttempts int
+	AppEmailRetryBaseDelay   time.Duration
+	AppEmailRetryMaxDelay    time.Duration
+
+	// Rate limit configuration
+	AppRateLimitRules         string
+	AppRateLimitStore         string
+	AppRateLimitTable         string
+	AppRateLimitIPMetadataKey string
+	AppRateLimitSecret        string
+
+	// Dedupe configuration
+	AppDedupeEnabled bool
+	AppDedupeStore   string
+	AppDedupeTable   string
+	AppDedupeWindow  time.Duration
+	AppDedupeSecret  string
+
+	// Suppression configuration
+	AppSuppressionSources  []string
+	AppSuppressionCacheTTL time.Duration
+	AppSuppressionTimeout  time.Duration
+	AppSuppressionTable    string
+
+	// Metrics configuration
+	AppMetricsEnabled   bool
+	AppMetricsNamespace string
+
+	// Tracing configuration
+	AppTracingExporter string
+
+	// Audit configuration
+	AppAuditSink   string
+	AppAuditTarget string
+
+	// Feedback events configuration
+	AppFeedbackTable         string
+	SendGridWebhookPublicKey string
+
+	// SMTP configuration
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTLSMode  string
+
+	// SMS configuration
+	AppSMSProvider                string
+	AppSMSSenderPolicyPath        string
+	AppSMSFailoverEnabled         bool
+	AppSMSFailoverProviders       []string
+	AppSMSFailoverAllFailedAction string
+	TwilioApiHost                 string
+	TwilioAccountSid              string
+	TwilioAuthToken               string
 }
 
+// DefaultDecisionLogRedactPaths removes recipient addresses, client metadata
+// (which carries the caller's IP), raw verification responses and any code a
+// policy copies into template data.
+var DefaultDecisionLogRedactPaths = []string{
+	"/input/userAttributes/email",
+	"/input/userAttributes/phone_number",
+	"/input/clientMetadata",
+	"/input/emailVerification/raw",
+	"/result/allow/dstAddress",
+	"/result/allow/dstAddresses",
+	"/result/allow/ccAddresses",
+	"/result/allow/bccAddresses",
+	"/result/allow/dstPhoneNumber",
+	"/result/allow/templateData/code",
+	"/result/allow/providers/*/templateData/code",
+}
+
+// New loads and validates the configuration of the sender Lambda.
 func New() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewEvents loads and validates the configuration of the feedback events
+// Lambda, which does not need the sender's policies or keys.
+func NewEvents() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateEvents(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func load() (*Config, error) {
 	awscfg, err := config.LoadDefaultConfig(context.Background())
 	if err != nil {
 		return nil, err
@@ -46,11 +170,22 @@ func New() (*Config, error) {
 		AWSConfig:                       &awscfg,
 		AppKmsKeyId:                     os.Getenv("APP_KMS_KEY_ID"),
 		AppLogLevel:                     slog.LevelInfo,
+		AppLogRedactKeys:                []string{},
 		AppEmailProvider:                os.Getenv("APP_EMAIL_PROVIDER"),
 		AppEmailSenderPolicyPath:        os.Getenv("APP_EMAIL_SENDER_POLICY_PATH"),
+		AppPolicyRefreshInterval:        60 * time.Second,
+		AppPolicyBundleToken:            os.Getenv("APP_POLICY_BUNDLE_TOKEN"),
+		AppDecisionLogSink:              os.Getenv("APP_DECISION_LOG_SINK"),
+		AppDecisionLogTarget:            os.Getenv("APP_DECISION_LOG_TARGET"),
+		AppDecisionLogRedactPaths:       DefaultDecisionLogRedactPaths,
+		AppEmailTemplatesPath:           os.Getenv("APP_EMAIL_TEMPLATES_PATH"),
 		AppEmailVerificationEnabled:     os.Getenv("APP_EMAIL_VERIFICATION_ENABLED") != "false",
 		AppEmailVerificationProvider:    os.Getenv("APP_EMAIL_VERIFICATION_PROVIDER"),
+		AppEmailVerificationFailureMode: os.Getenv("APP_EMAIL_VERIFICATION_FAILURE_MODE"),
 		AppEmailVerificationWhitelist:   []string{},
+		AppEmailVerificationDNSResolver: os.Getenv("APP_EMAIL_VERIFICATION_DNS_RESOLVER"),
+		AppEmailVerificationDNSTimeout:  2 * time.Second,
+		AppEmailVerificationDNSCacheTTL: 10 * time.Minute,
 		AppSendEnabled:                  true,
 		SendGridApiHost:                 os.Getenv("APP_SENDGRID_API_HOST"),
 		SendGridEmailSendApiKey:         os.Getenv("APP_SENDGRID_EMAIL_SEND_API_KEY"),
@@ -60,6 +195,74 @@ func New() (*Config, error) {
 		AppEmailFailoverEnabled:   os.Getenv("APP_EMAIL_FAILOVER_ENABLED") == "true",
 		AppEmailFailoverProviders: []string{},
 		AppEmailFailoverCacheTTL:  30 * time.Second,
+
+		// Failover circuit breaker defaults
+		AppEmailFailoverBreakerEnabled:           os.Getenv("APP_EMAIL_FAILOVER_BREAKER_ENABLED") == "true",
+		AppEmailFailoverBreakerFailureThreshold:  3,
+		AppEmailFailoverBreakerSlowCallThreshold: 0,
+		AppEmailFailoverBreakerCooldown:          60 * time.Second,
+
+		// Failover all-providers-failed defaults
+		AppEmailFailoverAllFailedAction: os.Getenv("APP_EMAIL_FAILOVER_ALL_FAILED_ACTION"),
+		AppEmailDeadLetterType:          os.Getenv("APP_EMAIL_DEADLETTER_TYPE"),
+		AppEmailDeadLetterTarget:        os.Getenv("APP_EMAIL_DEADLETTER_TARGET"),
+
+		// Retry defaults
+		AppEmailRetryMaxAttempts: 1,
+		AppEmailRetryBaseDelay:   100 * time.Millisecond,
+		AppEmailRetryMaxDelay:    time.Second,
+
+		// Rate limit defaults
+		AppRateLimitRules:         os.Getenv("APP_RATE_LIMIT_RULES"),
+		AppRateLimitStore:         os.Getenv("APP_RATE_LIMIT_STORE"),
+		AppRateLimitTable:         os.Getenv("APP_RATE_LIMIT_TABLE"),
+		AppRateLimitIPMetadataKey: os.Getenv("APP_RATE_LIMIT_IP_METADATA_KEY"),
+		AppRateLimitSecret:        os.Getenv("APP_RATE_LIMIT_SECRET"),
+
+		// Dedupe defaults
+		AppDedupeEnabled: os.Getenv("APP_DEDUPE_ENABLED") == "true",
+		AppDedupeStore:   os.Getenv("APP_DEDUPE_STORE"),
+		AppDedupeTable:   os.Getenv("APP_DEDUPE_TABLE"),
+		AppDedupeWindow:  5 * time.Minute,
+		AppDedupeSecret:  os.Getenv("APP_DEDUPE_SECRET"),
+
+		// Suppression defaults
+		AppSuppressionSources:  []string{},
+		AppSuppressionCacheTTL: 5 * time.Minute,
+		AppSuppressionTimeout:  2 * time.Second,
+		AppSuppressionTable:    os.Getenv("APP_SUPPRESSION_TABLE"),
+
+		// Metrics defaults
+		AppMetricsEnabled:   os.Getenv("APP_METRICS_ENABLED") == "true",
+		AppMetricsNamespace: os.Getenv("APP_METRICS_NAMESPACE"),
+
+		// Tracing defaults
+		AppTracingExporter: os.Getenv("APP_TRACING_EXPORTER"),
+
+		// Audit defaults
+		AppAuditSink:   os.Getenv("APP_AUDIT_SINK"),
+		AppAuditTarget: os.Getenv("APP_AUDIT_TARGET"),
+
+		// Feedback events defaults
+		AppFeedbackTable:         os.Getenv("APP_FEEDBACK_TABLE"),
+		SendGridWebhookPublicKey: os.Getenv("APP_SENDGRID_WEBHOOK_PUBLIC_KEY"),
+
+		// SMTP defaults
+		SMTPHost:     os.Getenv("APP_SMTP_HOST"),
+		SMTPPort:     587,
+		SMTPUsername: os.Getenv("APP_SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("APP_SMTP_PASSWORD"),
+		SMTPTLSMode:  os.Getenv("APP_SMTP_TLS_MODE"),
+
+		// SMS defaults
+		AppSMSProvider:                os.Getenv("APP_SMS_PROVIDER"),
+		AppSMSSenderPolicyPath:        os.Getenv("APP_SMS_SENDER_POLICY_PATH"),
+		AppSMSFailoverEnabled:         os.Getenv("APP_SMS_FAILOVER_ENABLED") == "true",
+		AppSMSFailoverProviders:       []string{},
+		AppSMSFailoverAllFailedAction: os.Getenv("APP_SMS_FAILOVER_ALL_FAILED_ACTION"),
+		TwilioApiHost:                 os.Getenv("APP_TWILIO_API_HOST"),
+		TwilioAccountSid:              os.Getenv("APP_TWILIO_ACCOUNT_SID"),
+		TwilioAuthToken:               os.Getenv("APP_TWILIO_AUTH_TOKEN"),
 	}
 
 	// disable send if debug mode by default
@@ -74,7 +277,7 @@ func New() (*Config, error) {
 		}
 	}
 
-	if cfg.AppEmailProvider == "" || (cfg.AppEmailProvider != "ses" && cfg.AppEmailProvider != "sendgrid") {
+	if cfg.AppEmailProvider == "" || (cfg.AppEmailProvider != "ses" && cfg.AppEmailProvider != "sendgrid" && cfg.AppEmailProvider != "smtp") {
 		slog.Warn("unknown email provider, defaulting to ses", "provider", cfg.AppEmailProvider)
 		cfg.AppEmailProvider = "ses"
 	}
@@ -88,10 +291,49 @@ func New() (*Config, error) {
 		cfg.AppEmailVerificationWhitelist = whitelist
 	}
 
+	if keysStr := strings.TrimSpace(os.Getenv("APP_LOG_REDACT_KEYS")); keysStr != "" {
+		for _, k := range strings.Split(keysStr, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				cfg.AppLogRedactKeys = append(cfg.AppLogRedactKeys, k)
+			}
+		}
+	}
+
+	// unredacted logs are only allowed for local debug runs
+	if os.Getenv("APP_LOG_REDACTION_DISABLED") == "true" {
+		if cfg.DebugMode {
+			cfg.AppLogRedactionDisabled = true
+		} else {
+			slog.Warn("APP_LOG_REDACTION_DISABLED is ignored outside debug mode")
+		}
+	}
+
+	if redactStr := strings.TrimSpace(os.Getenv("APP_DECISION_LOG_REDACT_PATHS")); redactStr != "" {
+		paths := []string{}
+		for _, p := range strings.Split(redactStr, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+		cfg.AppDecisionLogRedactPaths = paths
+	}
+
 	if cfg.SendGridApiHost == "" {
 		cfg.SendGridApiHost = "https://api.sendgrid.com"
 	}
 
+	if portStr := os.Getenv("APP_SMTP_PORT"); portStr != "" {
+		if port, err := strconv.Atoi(portStr); err == nil {
+			cfg.SMTPPort = port
+		} else {
+			slog.Warn("invalid APP_SMTP_PORT, using default", "value", portStr, "default", 587)
+		}
+	}
+
+	if cfg.SMTPTLSMode == "" {
+		cfg.SMTPTLSMode = "starttls"
+	}
+
 	// Parse failover providers
 	failoverProvidersStr := strings.TrimSpace(os.Getenv("APP_EMAIL_FAILOVER_PROVIDERS"))
 	if failoverProvidersStr != "" {
@@ -111,6 +353,157 @@ func New() (*Config, error) {
 		}
 	}
 
+	if thresholdStr := os.Getenv("APP_EMAIL_FAILOVER_BREAKER_FAILURE_THRESHOLD"); thresholdStr != "" {
+		if threshold, err := strconv.Atoi(thresholdStr); err == nil && threshold > 0 {
+			cfg.AppEmailFailoverBreakerFailureThreshold = threshold
+		} else {
+			slog.Warn("invalid APP_EMAIL_FAILOVER_BREAKER_FAILURE_THRESHOLD, using default", "value", thresholdStr, "default", 3)
+		}
+	}
+
+	if slowStr := os.Getenv("APP_EMAIL_FAILOVER_BREAKER_SLOW_CALL_THRESHOLD"); slowStr != "" {
+		if slow, err := time.ParseDuration(slowStr); err == nil {
+			cfg.AppEmailFailoverBreakerSlowCallThreshold = slow
+		} else {
+			slog.Warn("invalid APP_EMAIL_FAILOVER_BREAKER_SLOW_CALL_THRESHOLD, using default", "value", slowStr, "default", "0s")
+		}
+	}
+
+	if cooldownStr := os.Getenv("APP_EMAIL_FAILOVER_BREAKER_COOLDOWN"); cooldownStr != "" {
+		if cooldown, err := time.ParseDuration(cooldownStr); err == nil {
+			cfg.AppEmailFailoverBreakerCooldown = cooldown
+		} else {
+			slog.Warn("invalid APP_EMAIL_FAILOVER_BREAKER_COOLDOWN, using default", "value", cooldownStr, "default", "60s")
+		}
+	}
+
+	if attemptsStr := os.Getenv("APP_EMAIL_RETRY_MAX_ATTEMPTS"); attemptsStr != "" {
+		if attempts, err := strconv.Atoi(attemptsStr); err == nil && attempts > 0 {
+			cfg.AppEmailRetryMaxAttempts = attempts
+		} else {
+			slog.Warn("invalid APP_EMAIL_RETRY_MAX_ATTEMPTS, using default", "value", attemptsStr, "default", 1)
+		}
+	}
+
+	if delayStr := os.Getenv("APP_EMAIL_RETRY_BASE_DELAY"); delayStr != "" {
+		if delay, err := time.ParseDuration(delayStr); err == nil {
+			cfg.AppEmailRetryBaseDelay = delay
+		} else {
+			slog.Warn("invalid APP_EMAIL_RETRY_BASE_DELAY, using default", "value", delayStr, "default", "100ms")
+		}
+	}
+
+	if delayStr := os.Getenv("APP_EMAIL_RETRY_MAX_DELAY"); delayStr != "" {
+		if delay, err := time.ParseDuration(delayStr); err == nil {
+			cfg.AppEmailRetryMaxDelay = delay
+		} else {
+			slog.Warn("invalid APP_EMAIL_RETRY_MAX_DELAY, using default", "value", delayStr, "default", "1s")
+		}
+	}
+
+	if intervalStr := os.Getenv("APP_POLICY_REFRESH_INTERVAL"); intervalStr != "" {
+		if interval, err := time.ParseDuration(intervalStr); err == nil {
+			cfg.AppPolicyRefreshInterval = interval
+		} else {
+			slog.Warn("invalid APP_POLICY_REFRESH_INTERVAL, using default", "value", intervalStr, "default", "60s")
+		}
+	}
+
+	if timeoutStr := os.Getenv("APP_EMAIL_VERIFICATION_DNS_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil {
+			cfg.AppEmailVerificationDNSTimeout = timeout
+		} else {
+			slog.Warn("invalid APP_EMAIL_VERIFICATION_DNS_TIMEOUT, using default", "value", timeoutStr, "default", "2s")
+		}
+	}
+
+	if ttlStr := os.Getenv("APP_EMAIL_VERIFICATION_DNS_CACHE_TTL"); ttlStr != "" {
+		if ttl, err := time.ParseDuration(ttlStr); err == nil {
+			cfg.AppEmailVerificationDNSCacheTTL = ttl
+		} else {
+			slog.Warn("invalid APP_EMAIL_VERIFICATION_DNS_CACHE_TTL, using default", "value", ttlStr, "default", "10m")
+		}
+	}
+
+	if windowStr := os.Getenv("APP_DEDUPE_WINDOW"); windowStr != "" {
+		if window, err := time.ParseDuration(windowStr); err == nil && window > 0 {
+			cfg.AppDedupeWindow = window
+		} else {
+			slog.Warn("invalid APP_DEDUPE_WINDOW, using default", "value", windowStr, "default", "5m")
+		}
+	}
+
+	if cfg.AppMetricsNamespace == "" {
+		cfg.AppMetricsNamespace = "CognitoCustomMessageSender"
+	}
+
+	if cfg.AppDedupeStore == "" {
+		cfg.AppDedupeStore = "memory"
+	}
+
+	if cfg.AppRateLimitStore == "" {
+		cfg.AppRateLimitStore = "memory"
+	}
+
+	if cfg.AppRateLimitIPMetadataKey == "" {
+		cfg.AppRateLimitIPMetadataKey = "ip"
+	}
+
+	if cfg.AppEmailFailoverAllFailedAction == "" {
+		cfg.AppEmailFailoverAllFailedAction = "swallow"
+	}
+
+	if cfg.AppSMSFailoverAllFailedAction == "" {
+		cfg.AppSMSFailoverAllFailedAction = "swallow"
+	}
+
+	if cfg.AppSMSProvider == "" || (cfg.AppSMSProvider != "sns" && cfg.AppSMSProvider != "twilio") {
+		if cfg.AppSMSSenderPolicyPath != "" {
+			slog.Warn("unknown sms provider, defaulting to sns", "provider", cfg.AppSMSProvider)
+		}
+		cfg.AppSMSProvider = "sns"
+	}
+
+	if cfg.TwilioApiHost == "" {
+		cfg.TwilioApiHost = "https://api.twilio.com"
+	}
+
+	// Parse sms failover providers
+	smsFailoverProvidersStr := strings.TrimSpace(os.Getenv("APP_SMS_FAILOVER_PROVIDERS"))
+	if smsFailoverProvidersStr != "" {
+		providers := strings.Split(smsFailoverProvidersStr, ",")
+		for i, p := range providers {
+			providers[i] = strings.TrimSpace(p)
+		}
+		cfg.AppSMSFailoverProviders = providers
+	}
+
+	// Parse suppression sources
+	suppressionSourcesStr := strings.TrimSpace(os.Getenv("APP_SUPPRESSION_SOURCES"))
+	if suppressionSourcesStr != "" {
+		sources := strings.Split(suppressionSourcesStr, ",")
+		for i, src := range sources {
+			sources[i] = strings.TrimSpace(src)
+		}
+		cfg.AppSuppressionSources = sources
+	}
+
+	if ttlStr := os.Getenv("APP_SUPPRESSION_CACHE_TTL"); ttlStr != "" {
+		if ttl, err := time.ParseDuration(ttlStr); err == nil {
+			cfg.AppSuppressionCacheTTL = ttl
+		} else {
+			slog.Warn("invalid APP_SUPPRESSION_CACHE_TTL, using default", "value", ttlStr, "default", "5m")
+		}
+	}
+
+	if timeoutStr := os.Getenv("APP_SUPPRESSION_TIMEOUT"); timeoutStr != "" {
+		if timeout, err := time.ParseDuration(timeoutStr); err == nil {
+			cfg.AppSuppressionTimeout = timeout
+		} else {
+			slog.Warn("invalid APP_SUPPRESSION_TIMEOUT, using default", "value", timeoutStr, "default", "2s")
+		}
+	}
+
 	// deprecated
 	if cfg.AppKmsKeyId == "" && os.Getenv("KMS_KEY_ID") != "" {
 		cfg.AppKmsKeyId = os.Getenv("KMS_KEY_ID")
@@ -122,13 +515,22 @@ func New() (*Config, error) {
 		slog.Warn("deprecated env var used", "old", "APP_SENDGRID_API_KEY", "new", "APP_SENDGRID_EMAIL_VERIFICATION_API_KEY")
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
-	}
-
 	return &cfg, nil
 }
 
+// EmailVerificationProviders returns the verifiers of
+// APP_EMAIL_VERIFICATION_PROVIDER, which may be a comma-separated chain such as
+// "offline,dns,sendgrid".
+func (c *Config) EmailVerificationProviders() []string {
+	var names []string
+	for _, name := range strings.Split(c.AppEmailVerificationProvider, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // Validate checks that required configuration fields are set and valid
 func (c *Config) Validate() error {
 	if c.AppKmsKeyId == "" {
@@ -143,10 +545,22 @@ func (c *Config) Validate() error {
 		return errors.New("APP_SENDGRID_EMAIL_SEND_API_KEY is required when using sendgrid provider")
 	}
 
-	if c.AppEmailVerificationEnabled && c.AppEmailVerificationProvider == "sendgrid" && c.SendGridEmailVerificationApiKey == "" {
+	if c.AppEmailProvider == "smtp" && c.SMTPHost == "" {
+		return errors.New("APP_SMTP_HOST is required when using smtp provider")
+	}
+
+	if c.SMTPTLSMode != "starttls" && c.SMTPTLSMode != "tls" && c.SMTPTLSMode != "none" {
+		return errors.New("invalid APP_SMTP_TLS_MODE: " + c.SMTPTLSMode + " (must be 'starttls', 'tls' or 'none')")
+	}
+
+	if c.AppEmailVerificationEnabled && slices.Contains(c.EmailVerificationProviders(), "sendgrid") && c.SendGridEmailVerificationApiKey == "" {
 		return errors.New("APP_SENDGRID_EMAIL_VERIFICATION_API_KEY is required when using sendgrid email verification")
 	}
 
+	if c.AppEmailVerificationFailureMode != "" && c.AppEmailVerificationFailureMode != "open" && c.AppEmailVerificationFailureMode != "closed" {
+		return errors.New("invalid APP_EMAIL_VERIFICATION_FAILURE_MODE: " + c.AppEmailVerificationFailureMode + " (must be 'open' or 'closed')")
+	}
+
 	// Validate failover configuration
 	if c.AppEmailFailoverEnabled {
 		if len(c.AppEmailFailoverProviders) == 0 {
@@ -154,21 +568,143 @@ func (c *Config) Validate() error {
 		}
 
 		// Validate each failover provider
-		validProviders := map[string]bool{"ses": true, "sendgrid": true}
+		validProviders := map[string]bool{"ses": true, "sendgrid": true, "smtp": true}
 		for _, p := range c.AppEmailFailoverProviders {
 			if !validProviders[p] {
-				return errors.New("invalid failover provider: " + p + " (must be 'ses' or 'sendgrid')")
+				return errors.New("invalid failover provider: " + p + " (must be 'ses', 'sendgrid' or 'smtp')")
 			}
 		}
 
+		switch c.AppEmailFailoverAllFailedAction {
+		case "", "swallow", "error":
+		case "deadletter":
+			if c.AppEmailDeadLetterType != "sqs" && c.AppEmailDeadLetterType != "sns" && c.AppEmailDeadLetterType != "file" {
+				return errors.New("invalid APP_EMAIL_DEADLETTER_TYPE: " + c.AppEmailDeadLetterType + " (must be 'sqs', 'sns' or 'file')")
+			}
+			if c.AppEmailDeadLetterTarget == "" {
+				return errors.New("APP_EMAIL_DEADLETTER_TARGET is required when all-failed action is deadletter")
+			}
+		default:
+			return errors.New("invalid APP_EMAIL_FAILOVER_ALL_FAILED_ACTION: " + c.AppEmailFailoverAllFailedAction + " (must be 'swallow', 'error' or 'deadletter')")
+		}
+
 		// Check that credentials exist for each failover provider
 		allProviders := append([]string{c.AppEmailProvider}, c.AppEmailFailoverProviders...)
 		for _, p := range allProviders {
 			if p == "sendgrid" && c.SendGridEmailSendApiKey == "" {
 				return errors.New("APP_SENDGRID_EMAIL_SEND_API_KEY is required when sendgrid is in failover chain")
 			}
+			if p == "smtp" && c.SMTPHost == "" {
+				return errors.New("APP_SMTP_HOST is required when smtp is in failover chain")
+			}
+		}
+	}
+
+	if c.AppRateLimitRules != "" && c.AppRateLimitStore == "dynamodb" && c.AppRateLimitTable == "" {
+		return errors.New("APP_RATE_LIMIT_TABLE is required when using dynamodb rate limit store")
+	}
+
+	if c.AppRateLimitRules != "" && c.AppRateLimitStore == "dynamodb" && c.AppRateLimitSecret == "" {
+		return errors.New("APP_RATE_LIMIT_SECRET is required when using dynamodb rate limit store")
+	}
+
+	if c.AppDedupeEnabled && c.AppDedupeStore == "dynamodb" && c.AppDedupeTable == "" {
+		return errors.New("APP_DEDUPE_TABLE is required when using dynamodb dedupe store")
+	}
+
+	if c.AppDedupeEnabled && c.AppDedupeStore == "dynamodb" && c.AppDedupeSecret == "" {
+		return errors.New("APP_DEDUPE_SECRET is required when using dynamodb dedupe store")
+	}
+
+	for _, src := range c.AppSuppressionSources {
+		switch src {
+		case "ses":
+		case "sendgrid":
+			if c.SendGridEmailSendApiKey == "" {
+				return errors.New("APP_SENDGRID_EMAIL_SEND_API_KEY is required when sendgrid is a suppression source")
+			}
+		case "store":
+			if c.AppSuppressionTable == "" {
+				return errors.New("APP_SUPPRESSION_TABLE is required when store is a suppression source")
+			}
+		default:
+			return errors.New("invalid APP_SUPPRESSION_SOURCES entry: " + src + " (must be 'ses', 'sendgrid' or 'store')")
 		}
 	}
 
+	switch c.AppTracingExporter {
+	case "", "none", "otlp", "stdout":
+	default:
+		return errors.New("invalid APP_TRACING_EXPORTER: " + c.AppTracingExporter + " (must be 'none', 'otlp' or 'stdout')")
+	}
+
+	switch c.AppAuditSink {
+	case "":
+	case "dynamodb", "firehose", "kinesis", "file":
+		if c.AppAuditTarget == "" {
+			return errors.New("APP_AUDIT_TARGET is required when using " + c.AppAuditSink + " audit sink")
+		}
+	default:
+		return errors.New("invalid APP_AUDIT_SINK: " + c.AppAuditSink + " (must be 'dynamodb', 'firehose', 'kinesis' or 'file')")
+	}
+
+	switch c.AppDecisionLogSink {
+	case "", "stdout":
+	case "file", "http":
+		if c.AppDecisionLogTarget == "" {
+			return errors.New("APP_DECISION_LOG_TARGET is required when using " + c.AppDecisionLogSink + " decision log sink")
+		}
+	default:
+		return errors.New("invalid APP_DECISION_LOG_SINK: " + c.AppDecisionLogSink + " (must be 'stdout', 'file' or 'http')")
+	}
+
+	// Validate sms configuration
+	if c.AppSMSSenderPolicyPath != "" {
+		if c.AppSMSProvider == "twilio" && (c.TwilioAccountSid == "" || c.TwilioAuthToken == "") {
+			return errors.New("APP_TWILIO_ACCOUNT_SID and APP_TWILIO_AUTH_TOKEN are required when using twilio provider")
+		}
+
+		if c.AppSMSFailoverEnabled {
+			if len(c.AppSMSFailoverProviders) == 0 {
+				return errors.New("APP_SMS_FAILOVER_PROVIDERS is required when sms failover is enabled")
+			}
+
+			validProviders := map[string]bool{"sns": true, "twilio": true}
+			for _, p := range c.AppSMSFailoverProviders {
+				if !validProviders[p] {
+					return errors.New("invalid sms failover provider: " + p + " (must be 'sns' or 'twilio')")
+				}
+				if p == "twilio" && (c.TwilioAccountSid == "" || c.TwilioAuthToken == "") {
+					return errors.New("APP_TWILIO_ACCOUNT_SID and APP_TWILIO_AUTH_TOKEN are required when twilio is in sms failover chain")
+				}
+			}
+
+			// sms shares the dead-letter sink of the email chain
+			switch c.AppSMSFailoverAllFailedAction {
+			case "", "swallow", "error":
+			case "deadletter":
+				if c.AppEmailDeadLetterType != "sqs" && c.AppEmailDeadLetterType != "sns" && c.AppEmailDeadLetterType != "file" {
+					return errors.New("invalid APP_EMAIL_DEADLETTER_TYPE: " + c.AppEmailDeadLetterType + " (must be 'sqs', 'sns' or 'file')")
+				}
+				if c.AppEmailDeadLetterTarget == "" {
+					return errors.New("APP_EMAIL_DEADLETTER_TARGET is required when sms all-failed action is deadletter")
+				}
+			default:
+				return errors.New("invalid APP_SMS_FAILOVER_ALL_FAILED_ACTION: " + c.AppSMSFailoverAllFailedAction + " (must be 'swallow', 'error' or 'deadletter')")
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateEvents checks the configuration of the feedback events Lambda.
+func (c *Config) ValidateEvents() error {
+	if c.AppSuppressionTable == "" {
+		return errors.New("APP_SUPPRESSION_TABLE is required")
+	}
+	if c.AppFeedbackTable == "" {
+		return errors.New("APP_FEEDBACK_TABLE is required")
+	}
 	return nil
 }
@@ -0,0 +1,117 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// Sink receives emails and sms messages that no provider could deliver so
+// they can be replayed later.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, m *Message) error
+}
+
+// Message is an undelivered email or sms. It never contains the plaintext
+// code; Event holds the original Cognito event with the still-encrypted code
+// so a replay can re-invoke the sender with it.
+type Message struct {
+	Timestamp time.Time        `json:"timestamp"`
+	Reason    string           `json:"reason"`
+	Providers []string         `json:"providers"`
+	Email     *types.EmailData `json:"email,omitempty"`
+	SMS       *types.SMSData   `json:"sms,omitempty"`
+	Event     json.RawMessage  `json:"event,omitempty"`
+}
+
+type eventKey struct{}
+
+// ContextWithEvent attaches the raw Cognito event to ctx for dead-letter
+// messages created further down the call chain.
+func ContextWithEvent(ctx context.Context, event json.RawMessage) context.Context {
+	return context.WithValue(ctx, eventKey{}, event)
+}
+
+// EventFromContext returns the raw Cognito event attached to ctx, if any.
+func EventFromContext(ctx context.Context) json.RawMessage {
+	event, _ := ctx.Value(eventKey{}).(json.RawMessage)
+	return event
+}
+
+// NewMessage builds a dead-letter message for d. The merged "code" is removed
+// from the top-level and every provider's template data, which v1 policies
+// share as one map.
+func NewMessage(ctx context.Context, d *types.EmailData, providers []string, reason error) *Message {
+	email := *d
+	email.TemplateData = WithoutCodeData(d.TemplateData)
+	if d.Providers != nil {
+		email.Providers = &types.EmailProviderMap{
+			SendGrid: withoutCode(d.Providers.SendGrid),
+			SES:      withoutCode(d.Providers.SES),
+			SMTP:     withoutCode(d.Providers.SMTP),
+		}
+	}
+
+	m := newMessage(ctx, providers, reason)
+	m.Email = &email
+	return m
+}
+
+// NewSMSMessage builds a dead-letter message for an undelivered sms. The
+// message keeps its code placeholder.
+func NewSMSMessage(ctx context.Context, d *types.SMSData, providers []string, reason error) *Message {
+	sms := *d
+	sms.VerificationCode = ""
+
+	m := newMessage(ctx, providers, reason)
+	m.SMS = &sms
+	return m
+}
+
+func newMessage(ctx context.Context, providers []string, reason error) *Message {
+	m := &Message{
+		Timestamp: time.Now().UTC(),
+		Providers: providers,
+		Event:     EventFromContext(ctx),
+	}
+	if reason != nil {
+		m.Reason = reason.Error()
+	}
+	return m
+}
+
+func withoutCode(pd *types.EmailProviderData) *types.EmailProviderData {
+	if pd == nil {
+		return nil
+	}
+	out := *pd
+	out.TemplateData = WithoutCodeData(pd.TemplateData)
+	return &out
+}
+
+// WithoutCodeData returns a copy of template data without the merged "code".
+func WithoutCodeData(data map[string]any) map[string]any {
+	out := maps.Clone(data)
+	delete(out, "code")
+	return out
+}
+
+// NewSink creates the dead-letter sink selected by configuration.
+func NewSink(cfg *config.Config) (Sink, error) {
+	switch cfg.AppEmailDeadLetterType {
+	case "sqs":
+		return NewSQSSink(cfg), nil
+	case "sns":
+		return NewSNSSink(cfg), nil
+	case "file":
+		return NewFileSink(cfg.AppEmailDeadLetterTarget), nil
+	default:
+		return nil, fmt.Errorf("unknown dead-letter type: %s", cfg.AppEmailDeadLetterType)
+	}
+}
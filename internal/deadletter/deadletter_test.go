@@ -0,0 +1,141 @@
+package deadletter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+type mockSQSClient struct {
+	input *sqs.SendMessageInput
+}
+
+func (m *mockSQSClient) SendMessage(ctx context.Context, input *sqs.SendMessageInput, opts ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	m.input = input
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func newTestEmailData() *types.EmailData {
+	return &types.EmailData{
+		SourceAddress:      "noreply@example.com",
+		DestinationAddress: "user@example.com",
+		VerificationCode:   "123456",
+		Providers: &types.EmailProviderMap{
+			SES: &types.EmailProviderData{
+				TemplateID:   "welcome",
+				TemplateData: map[string]any{"appName": "ACME", "code": "123456"},
+			},
+		},
+	}
+}
+
+func TestNewMessage_OmitsCode(t *testing.T) {
+	d := newTestEmailData()
+	ctx := ContextWithEvent(context.Background(), json.RawMessage(`{"request":{"code":"encrypted"}}`))
+
+	m := NewMessage(ctx, d, []string{"ses"}, errors.New("all providers failed"))
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "123456") {
+		t.Errorf("expected plaintext code to be omitted, got %s", body)
+	}
+	if _, ok := d.Providers.SES.TemplateData["code"]; !ok {
+		t.Error("expected original template data to be left untouched")
+	}
+	if string(m.Event) != `{"request":{"code":"encrypted"}}` {
+		t.Errorf("expected encrypted event to be attached, got %s", m.Event)
+	}
+	if m.Reason != "all providers failed" {
+		t.Errorf("expected reason, got %q", m.Reason)
+	}
+}
+
+func TestNewMessage_OmitsCodeFromSharedV1TemplateData(t *testing.T) {
+	// v1 policies set top-level template data, which the sender shares with
+	// the ses provider data that the code is merged into
+	data := map[string]any{"appName": "ACME"}
+	d := &types.EmailData{
+		SourceAddress:      "noreply@example.com",
+		DestinationAddress: "user@example.com",
+		TemplateID:         "welcome",
+		TemplateData:       data,
+		Providers: &types.EmailProviderMap{
+			SES: &types.EmailProviderData{TemplateID: "welcome", TemplateData: data},
+		},
+	}
+	data["code"] = "987654"
+
+	m := NewMessage(context.Background(), d, []string{"ses"}, errors.New("all providers failed"))
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "987654") {
+		t.Errorf("expected plaintext code to be omitted, got %s", body)
+	}
+	if d.TemplateData["code"] != "987654" {
+		t.Error("expected original template data to be left untouched")
+	}
+}
+
+func TestFileSink_AppendsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletter.jsonl")
+	sink := NewFileSink(path)
+
+	for i := 0; i < 2; i++ {
+		if err := sink.Publish(context.Background(), NewMessage(context.Background(), newTestEmailData(), []string{"ses"}, nil)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var m Message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("invalid json line: %v", err)
+		}
+		if m.Email.DestinationAddress != "user@example.com" {
+			t.Errorf("unexpected destination: %s", m.Email.DestinationAddress)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestSQSSink_Publish(t *testing.T) {
+	client := &mockSQSClient{}
+	sink := &SQSSink{Client: client, QueueURL: "https://sqs.us-east-1.amazonaws.com/123456789012/deadletter"}
+
+	if err := sink.Publish(context.Background(), NewMessage(context.Background(), newTestEmailData(), []string{"ses"}, nil)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if client.input == nil || *client.input.QueueUrl != sink.QueueURL {
+		t.Fatalf("expected message sent to queue, got %+v", client.input)
+	}
+	var m Message
+	if err := json.Unmarshal([]byte(*client.input.MessageBody), &m); err != nil {
+		t.Fatalf("expected json body: %v", err)
+	}
+}
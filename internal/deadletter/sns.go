@@ -0,0 +1,50 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// SNSAPI is the subset of the SNS client used by SNSSink.
+type SNSAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSSink publishes dead-letter messages to an SNS topic.
+type SNSSink struct {
+	Client   SNSAPI
+	TopicARN string
+}
+
+func NewSNSSink(cfg *config.Config) *SNSSink {
+	return &SNSSink{
+		Client:   sns.NewFromConfig(*cfg.AWSConfig),
+		TopicARN: cfg.AppEmailDeadLetterTarget,
+	}
+}
+
+func (s *SNSSink) Name() string {
+	return "sns"
+}
+
+func (s *SNSSink) Publish(ctx context.Context, m *Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("error marshaling dead-letter message: %w", err)
+	}
+
+	_, err = s.Client.Publish(ctx, &sns.PublishInput{
+		TopicArn: awssdk.String(s.TopicARN),
+		Message:  awssdk.String(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("error publishing dead-letter message: %w", err)
+	}
+
+	return nil
+}
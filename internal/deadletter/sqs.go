@@ -0,0 +1,50 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// SQSAPI is the subset of the SQS client used by SQSSink.
+type SQSAPI interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// SQSSink sends dead-letter messages to an SQS queue.
+type SQSSink struct {
+	Client   SQSAPI
+	QueueURL string
+}
+
+func NewSQSSink(cfg *config.Config) *SQSSink {
+	return &SQSSink{
+		Client:   sqs.NewFromConfig(*cfg.AWSConfig),
+		QueueURL: cfg.AppEmailDeadLetterTarget,
+	}
+}
+
+func (s *SQSSink) Name() string {
+	return "sqs"
+}
+
+func (s *SQSSink) Publish(ctx context.Context, m *Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("error marshaling dead-letter message: %w", err)
+	}
+
+	_, err = s.Client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    awssdk.String(s.QueueURL),
+		MessageBody: awssdk.String(string(body)),
+	})
+	if err != nil {
+		return fmt.Errorf("error sending dead-letter message: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package decisionlog records policy decisions in the OPA decision log format.
+package decisionlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/google/uuid"
+)
+
+// Sink receives decision log events.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e *Event) error
+}
+
+// Event is a decision log event compatible with OPA's decision log schema.
+type Event struct {
+	Labels     map[string]string     `json:"labels,omitempty"`
+	DecisionID string                `json:"decision_id"`
+	Bundles    map[string]BundleInfo `json:"bundles,omitempty"`
+	Path       string                `json:"path"`
+	Input      any                   `json:"input,omitempty"`
+	Result     any                   `json:"result,omitempty"`
+	Erased     []string              `json:"erased,omitempty"`
+	Error      *EventError           `json:"error,omitempty"`
+	Timestamp  time.Time             `json:"timestamp"`
+	Metrics    map[string]any        `json:"metrics,omitempty"`
+}
+
+// BundleInfo identifies the bundle revision a decision was made with.
+type BundleInfo struct {
+	Revision string `json:"revision"`
+}
+
+// EventError describes a failed evaluation.
+type EventError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Decision is a single policy evaluation as seen by the caller.
+type Decision struct {
+	Query    string
+	Revision string
+	Input    any
+	Result   any
+	Err      error
+	Latency  time.Duration
+}
+
+// Logger turns decisions into redacted events and publishes them to a sink.
+// Publishing errors are logged and never fail the evaluation.
+type Logger struct {
+	Sink Sink
+	// RedactPaths are JSON pointers rooted at /input or /result. A "*" segment
+	// matches every key or index at that level.
+	RedactPaths []string
+	Labels      map[string]string
+}
+
+// NewLogger creates the decision logger selected by configuration. It returns
+// nil when decision logging is disabled.
+func NewLogger(cfg *config.Config) (*Logger, error) {
+	if cfg.AppDecisionLogSink == "" {
+		return nil, nil
+	}
+
+	sink, err := NewSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{
+		Sink:        sink,
+		RedactPaths: cfg.AppDecisionLogRedactPaths,
+		Labels:      map[string]string{"id": uuid.NewString(), "app": "cognito-custom-message-sender"},
+	}, nil
+}
+
+// NewSink creates the decision log sink selected by configuration.
+func NewSink(cfg *config.Config) (Sink, error) {
+	switch cfg.AppDecisionLogSink {
+	case "stdout":
+		return NewStdoutSink(), nil
+	case "file":
+		return NewFileSink(cfg.AppDecisionLogTarget), nil
+	case "http":
+		return NewHTTPSink(cfg.AppDecisionLogTarget), nil
+	default:
+		return nil, fmt.Errorf("unknown decision log sink: %s", cfg.AppDecisionLogSink)
+	}
+}
+
+// Log publishes d. It is safe to call on a nil logger.
+func (l *Logger) Log(ctx context.Context, d *Decision) {
+	if l == nil {
+		return
+	}
+
+	e, err := l.newEvent(d)
+	if err == nil {
+		err = l.Sink.Publish(ctx, e)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to publish decision log", "sink", l.Sink.Name(), "error", err)
+	}
+}
+
+func (l *Logger) newEvent(d *Decision) (*Event, error) {
+	input, err := toJSONValue(d.Input)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding decision input: %w", err)
+	}
+	result, err := toJSONValue(d.Result)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding decision result: %w", err)
+	}
+
+	doc := map[string]any{"input": input, "result": result}
+	var erased []string
+	for _, path := range l.RedactPaths {
+		if redact(doc, splitPointer(path)) {
+			erased = append(erased, path)
+		}
+	}
+
+	e := &Event{
+		Labels:     l.Labels,
+		DecisionID: uuid.NewString(),
+		Path:       queryPath(d.Query),
+		Input:      doc["input"],
+		Result:     doc["result"],
+		Erased:     erased,
+		Timestamp:  time.Now().UTC(),
+		Metrics:    map[string]any{"timer_rego_query_eval_ns": d.Latency.Nanoseconds()},
+	}
+	if d.Revision != "" {
+		e.Bundles = map[string]BundleInfo{"policy": {Revision: d.Revision}}
+	}
+	if d.Err != nil {
+		e.Error = &EventError{Code: "eval_error", Message: d.Err.Error()}
+	}
+
+	return e, nil
+}
+
+// queryPath converts a query such as data.pkg.result to the pkg/result path
+// used in OPA decision logs.
+func queryPath(query string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(query, "data."), ".", "/")
+}
+
+// toJSONValue converts v to its generic JSON representation.
+func toJSONValue(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	if err := json.Unmarshal(bs, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// splitPointer splits a JSON pointer into unescaped segments.
+func splitPointer(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return nil
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return segments
+}
+
+// redact removes the value at path from doc and reports whether anything was
+// removed.
+func redact(doc any, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	key, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if key == "*" {
+			removed := false
+			for k, child := range node {
+				if len(rest) == 0 {
+					delete(node, k)
+					removed = true
+				} else if redact(child, rest) {
+					removed = true
+				}
+			}
+			return removed
+		}
+		child, ok := node[key]
+		if !ok {
+			return false
+		}
+		if len(rest) == 0 {
+			delete(node, key)
+			return true
+		}
+		return redact(child, rest)
+	case []any:
+		removed := false
+		for i, child := range node {
+			if key != "*" && key != fmt.Sprint(i) {
+				continue
+			}
+			if len(rest) == 0 {
+				node[i] = nil
+				removed = true
+			} else if redact(child, rest) {
+				removed = true
+			}
+		}
+		return removed
+	default:
+		return false
+	}
+}
@@ -0,0 +1,245 @@
+package decisionlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// mockSink records published events for testing
+type mockSink struct {
+	events []*Event
+	err    error
+}
+
+func (m *mockSink) Name() string { return "mock" }
+
+func (m *mockSink) Publish(ctx context.Context, e *Event) error {
+	m.events = append(m.events, e)
+	return m.err
+}
+
+type testInput struct {
+	Trigger        string            `json:"trigger"`
+	UserAttributes map[string]any    `json:"userAttributes"`
+	ClientMetadata map[string]string `json:"clientMetadata,omitempty"`
+}
+
+func newTestDecision() *Decision {
+	return &Decision{
+		Query:    "data.cognito_custom_sender_email_policy.result",
+		Revision: "r42",
+		Input: testInput{
+			Trigger:        "CustomEmailSender_SignUp",
+			UserAttributes: map[string]any{"email": "user@example.com", "sub": "uuid"},
+		},
+		Result: map[string]any{
+			"action": "allow",
+			"allow": map[string]any{
+				"dstAddress": "user@example.com",
+				"providers": map[string]any{
+					"ses":      map[string]any{"templateData": map[string]any{"code": "123456", "app": "acme"}},
+					"sendgrid": map[string]any{"templateData": map[string]any{"code": "123456"}},
+				},
+			},
+		},
+		Latency: 3 * time.Millisecond,
+	}
+}
+
+func TestLogger_RedactsAndFormats(t *testing.T) {
+	sink := &mockSink{}
+	l := &Logger{
+		Sink: sink,
+		RedactPaths: []string{
+			"/input/userAttributes/email",
+			"/result/allow/dstAddress",
+			"/result/allow/providers/*/templateData/code",
+			"/input/missing",
+		},
+	}
+
+	l.Log(context.Background(), newTestDecision())
+
+	if len(sink.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(sink.events))
+	}
+	e := sink.events[0]
+
+	bs, _ := json.Marshal(e)
+	out := string(bs)
+	if strings.Contains(out, "user@example.com") || strings.Contains(out, "123456") {
+		t.Errorf("expected redacted event, got %s", out)
+	}
+	if !strings.Contains(out, `"app":"acme"`) || !strings.Contains(out, `"sub":"uuid"`) {
+		t.Errorf("expected unredacted fields to remain, got %s", out)
+	}
+
+	if e.Path != "cognito_custom_sender_email_policy/result" {
+		t.Errorf("unexpected path %q", e.Path)
+	}
+	if e.Bundles["policy"].Revision != "r42" {
+		t.Errorf("expected bundle revision, got %+v", e.Bundles)
+	}
+	if e.Metrics["timer_rego_query_eval_ns"] != int64(3*time.Millisecond) {
+		t.Errorf("expected latency metric, got %+v", e.Metrics)
+	}
+	if e.DecisionID == "" {
+		t.Error("expected decision id")
+	}
+	if slices.Contains(e.Erased, "/input/missing") || len(e.Erased) != 3 {
+		t.Errorf("expected only removed paths to be listed as erased, got %v", e.Erased)
+	}
+}
+
+func TestLogger_DefaultRedactPaths(t *testing.T) {
+	sink := &mockSink{}
+	l := &Logger{Sink: sink, RedactPaths: config.DefaultDecisionLogRedactPaths}
+
+	d := newTestDecision()
+	d.Input = testInput{
+		Trigger:        "CustomEmailSender_ForgotPassword",
+		UserAttributes: map[string]any{"email": "user@example.com", "sub": "uuid"},
+		ClientMetadata: map[string]string{"ip": "203.0.113.7"},
+	}
+	l.Log(context.Background(), d)
+
+	bs, _ := json.Marshal(sink.events[0])
+	out := string(bs)
+	for _, secret := range []string{"user@example.com", "123456", "203.0.113.7"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("expected %q to be redacted by default, got %s", secret, out)
+		}
+	}
+	if !slices.Contains(sink.events[0].Erased, "/input/clientMetadata") {
+		t.Errorf("expected client metadata to be listed as erased, got %v", sink.events[0].Erased)
+	}
+}
+
+func TestLogger_EvalError(t *testing.T) {
+	sink := &mockSink{}
+	l := &Logger{Sink: sink}
+
+	d := newTestDecision()
+	d.Result = nil
+	d.Err = errors.New("no results")
+	l.Log(context.Background(), d)
+
+	e := sink.events[0]
+	if e.Error == nil || e.Error.Message != "no results" {
+		t.Errorf("expected error in event, got %+v", e.Error)
+	}
+}
+
+func TestLogger_NilAndSinkError(t *testing.T) {
+	var l *Logger
+	l.Log(context.Background(), newTestDecision()) // must not panic
+
+	l = &Logger{Sink: &mockSink{err: errors.New("down")}}
+	l.Log(context.Background(), newTestDecision()) // errors are only logged
+}
+
+func TestRedact_ArrayIndex(t *testing.T) {
+	doc := map[string]any{"list": []any{"a", map[string]any{"secret": "x", "keep": "y"}}}
+
+	if !redact(doc, splitPointer("/list/1/secret")) {
+		t.Fatal("expected value to be removed")
+	}
+	item := doc["list"].([]any)[1].(map[string]any)
+	if _, ok := item["secret"]; ok {
+		t.Error("expected secret to be removed")
+	}
+	if item["keep"] != "y" {
+		t.Error("expected sibling to remain")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := &WriterSink{W: &buf}
+
+	if err := s.Publish(context.Background(), &Event{DecisionID: "id-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "\n") || !strings.Contains(buf.String(), `"decision_id":"id-1"`) {
+		t.Errorf("expected a json line, got %q", buf.String())
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.jsonl")
+	s := NewFileSink(path)
+
+	for _, id := range []string{"id-1", "id-2"} {
+		if err := s.Publish(context.Background(), &Event{DecisionID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(bs), "\n"); lines != 2 {
+		t.Errorf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var got []Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s := NewHTTPSink(ts.URL)
+	if err := s.Publish(context.Background(), &Event{DecisionID: "id-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].DecisionID != "id-1" {
+		t.Errorf("expected event array, got %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := NewHTTPSink(failing.URL).Publish(context.Background(), &Event{}); err == nil {
+		t.Error("expected error for failed post")
+	}
+}
+
+func TestLogger_SlowHTTPSink(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	sink := NewHTTPSink(slow.URL)
+	sink.Client.Timeout = 50 * time.Millisecond
+	l := &Logger{Sink: sink}
+
+	start := time.Now()
+	l.Log(context.Background(), &Decision{Query: "data.test.result", Input: map[string]any{}})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected slow collector to be cut off by the client timeout, took %s", elapsed)
+	}
+}
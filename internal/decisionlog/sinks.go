@@ -0,0 +1,124 @@
+package decisionlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// httpSinkTimeout bounds a decision log post. Events are published during the
+// evaluation, so a slow collector must not hold up the send.
+const httpSinkTimeout = 2 * time.Second
+
+// WriterSink writes decision log events as JSON lines to a writer.
+type WriterSink struct {
+	W io.Writer
+
+	mu sync.Mutex
+}
+
+// NewStdoutSink writes events to stdout, where Lambda forwards them to
+// CloudWatch Logs.
+func NewStdoutSink() *WriterSink {
+	return &WriterSink{W: os.Stdout}
+}
+
+func (s *WriterSink) Name() string {
+	return "stdout"
+}
+
+func (s *WriterSink) Publish(ctx context.Context, e *Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error marshaling decision log: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.W.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing decision log: %w", err)
+	}
+	return nil
+}
+
+// FileSink appends decision log events as JSON lines to a local file.
+type FileSink struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{Path: path}
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) Publish(ctx context.Context, e *Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error marshaling decision log: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening decision log file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing decision log file: %w", err)
+	}
+	return nil
+}
+
+// HTTPSink posts events to an HTTP endpoint as a JSON array, the payload
+// format of OPA's decision log service API.
+type HTTPSink struct {
+	Client *http.Client
+	URL    string
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{Client: &http.Client{Timeout: httpSinkTimeout}, URL: url}
+}
+
+func (s *HTTPSink) Name() string {
+	return "http"
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, e *Event) error {
+	body, err := json.Marshal([]*Event{e})
+	if err != nil {
+		return fmt.Errorf("error marshaling decision log: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating decision log request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting decision log: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error posting decision log: status=%d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package dedupe suppresses repeated sends of the same code when Cognito or
+// Lambda retries an invocation.
+package dedupe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// ErrDuplicate is returned by Deduper.Acquire when the key was already
+// claimed within the window.
+var ErrDuplicate = errors.New("duplicate send")
+
+// Store records claimed idempotency keys.
+type Store interface {
+	// Claim records key for ttl. It returns false if key is already recorded
+	// and has not expired.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release removes key so a later attempt can claim it again.
+	Release(ctx context.Context, key string) error
+}
+
+// Deduper claims an idempotency key per send in a Store.
+type Deduper struct {
+	Store  Store
+	Window time.Duration
+	// Secret keys the HMAC of idempotency keys so a stored key cannot be
+	// brute-forced back to its code.
+	Secret []byte
+}
+
+// NewDeduper creates a Deduper from configuration. It returns nil if
+// deduplication is disabled.
+func NewDeduper(cfg *config.Config) (*Deduper, error) {
+	if !cfg.AppDedupeEnabled {
+		return nil, nil
+	}
+
+	var store Store
+	switch cfg.AppDedupeStore {
+	case "dynamodb":
+		store = NewDynamoDBStore(cfg)
+	case "memory", "":
+		store = NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown dedupe store: %s", cfg.AppDedupeStore)
+	}
+
+	// keys of the memory store never leave the instance, so a random secret
+	// will do; a shared store needs the same secret on every instance
+	secret := []byte(cfg.AppDedupeSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("error generating dedupe secret: %w", err)
+		}
+	}
+
+	return &Deduper{Store: store, Window: cfg.AppDedupeWindow, Secret: secret}, nil
+}
+
+// Key returns the idempotency key of a send, an HMAC of the user pool, user,
+// trigger and code, so neither the code nor the user identifiers can be
+// recovered from the store. It returns "" on a nil deduper.
+func (d *Deduper) Key(userPoolID, sub, trigger, code string) string {
+	if d == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, d.Secret)
+	mac.Write([]byte(strings.Join([]string{userPoolID, sub, trigger, code}, "|")))
+	return "dedupe#" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Acquire claims key for the window. It returns ErrDuplicate if the key was
+// already claimed.
+func (d *Deduper) Acquire(ctx context.Context, key string) error {
+	ok, err := d.Store.Claim(ctx, key, d.Window)
+	if err != nil {
+		return fmt.Errorf("error claiming idempotency key: %w", err)
+	}
+	if !ok {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+// Release frees key after a failed send so a retry is delivered.
+func (d *Deduper) Release(ctx context.Context, key string) error {
+	if err := d.Store.Release(ctx, key); err != nil {
+		return fmt.Errorf("error releasing idempotency key: %w", err)
+	}
+	return nil
+}
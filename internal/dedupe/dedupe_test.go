@@ -0,0 +1,123 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestKey(t *testing.T) {
+	d := &Deduper{Secret: []byte("secret-1")}
+	k := d.Key("us-east-1_abc", "user-1", "CustomEmailSender_SignUp", "123456")
+	if k != d.Key("us-east-1_abc", "user-1", "CustomEmailSender_SignUp", "123456") {
+		t.Error("expected key to be stable")
+	}
+	if strings.Contains(k, "123456") || strings.Contains(k, "user-1") {
+		t.Errorf("expected key to be hashed, got %s", k)
+	}
+	other := &Deduper{Secret: []byte("secret-2")}
+	for _, other := range []string{
+		d.Key("us-east-1_xyz", "user-1", "CustomEmailSender_SignUp", "123456"),
+		d.Key("us-east-1_abc", "user-2", "CustomEmailSender_SignUp", "123456"),
+		d.Key("us-east-1_abc", "user-1", "CustomEmailSender_ResendCode", "123456"),
+		d.Key("us-east-1_abc", "user-1", "CustomEmailSender_SignUp", "654321"),
+		other.Key("us-east-1_abc", "user-1", "CustomEmailSender_SignUp", "123456"),
+	} {
+		if other == k {
+			t.Error("expected different inputs to produce different keys")
+		}
+	}
+}
+
+func TestDeduper_MemoryStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	d := &Deduper{Store: store, Window: 5 * time.Minute}
+	ctx := context.Background()
+
+	if err := d.Acquire(ctx, "k"); err != nil {
+		t.Fatalf("expected first acquire to succeed, got: %v", err)
+	}
+	if err := d.Acquire(ctx, "k"); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("expected ErrDuplicate, got: %v", err)
+	}
+
+	// a failed send releases the key for the retry
+	if err := d.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Acquire(ctx, "k"); err != nil {
+		t.Fatalf("expected acquire after release to succeed, got: %v", err)
+	}
+
+	now = now.Add(6 * time.Minute)
+	if err := d.Acquire(ctx, "k"); err != nil {
+		t.Fatalf("expected acquire after window to succeed, got: %v", err)
+	}
+}
+
+// fakeDynamoDB evaluates the claim condition against in-memory items.
+type fakeDynamoDB struct {
+	items map[string]int64
+	err   error
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	pk := in.Item["pk"].(*ddbtypes.AttributeValueMemberS).Value
+	now, _ := strconv.ParseInt(in.ExpressionAttributeValues[":now"].(*ddbtypes.AttributeValueMemberN).Value, 10, 64)
+	if expiresAt, ok := f.items[pk]; ok && expiresAt >= now {
+		return nil, &ddbtypes.ConditionalCheckFailedException{}
+	}
+	f.items[pk], _ = strconv.ParseInt(in.Item["expires_at"].(*ddbtypes.AttributeValueMemberN).Value, 10, 64)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	delete(f.items, in.Key["pk"].(*ddbtypes.AttributeValueMemberS).Value)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	client := &fakeDynamoDB{items: map[string]int64{}}
+	s := &DynamoDBStore{Client: client, Table: "dedupe", now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	if ok, err := s.Claim(ctx, "k", time.Minute); err != nil || !ok {
+		t.Fatalf("expected claim, got ok=%v err=%v", ok, err)
+	}
+	if ok, err := s.Claim(ctx, "k", time.Minute); err != nil || ok {
+		t.Fatalf("expected duplicate, got ok=%v err=%v", ok, err)
+	}
+
+	// expired items are overwritten before ttl deletion catches up
+	now = now.Add(2 * time.Minute)
+	if ok, _ := s.Claim(ctx, "k", time.Minute); !ok {
+		t.Error("expected expired key to be claimable")
+	}
+
+	if err := s.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Claim(ctx, "k", time.Minute); !ok {
+		t.Error("expected released key to be claimable")
+	}
+
+	client.err = errors.New("throttled")
+	if _, err := s.Claim(ctx, "other", time.Minute); err == nil {
+		t.Error("expected error from client")
+	}
+}
@@ -0,0 +1,76 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore.
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDBStore keeps keys in a DynamoDB table with a string partition key
+// "pk". Items carry an "expires_at" epoch attribute for DynamoDB TTL; since
+// TTL deletion is lazy, expired items are overwritten on claim.
+type DynamoDBStore struct {
+	Client DynamoDBAPI
+	Table  string
+	now    func() time.Time
+}
+
+func NewDynamoDBStore(cfg *config.Config) *DynamoDBStore {
+	return &DynamoDBStore{
+		Client: dynamodb.NewFromConfig(*cfg.AWSConfig),
+		Table:  cfg.AppDedupeTable,
+	}
+}
+
+func (s *DynamoDBStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now()
+
+	_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: awssdk.String(s.Table),
+		Item: map[string]ddbtypes.AttributeValue{
+			"pk":         &ddbtypes.AttributeValueMemberS{Value: key},
+			"expires_at": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(t.Add(ttl).Unix(), 10)},
+		},
+		ConditionExpression: awssdk.String("attribute_not_exists(pk) OR expires_at < :now"),
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":now": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)},
+		},
+	})
+	if err != nil {
+		var condErr *ddbtypes.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error writing idempotency key: %w", err)
+	}
+
+	return true, nil
+}
+
+func (s *DynamoDBStore) Release(ctx context.Context, key string) error {
+	_, err := s.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: awssdk.String(s.Table),
+		Key:       map[string]ddbtypes.AttributeValue{"pk": &ddbtypes.AttributeValueMemberS{Value: key}},
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting idempotency key: %w", err)
+	}
+	return nil
+}
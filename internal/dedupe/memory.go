@@ -0,0 +1,47 @@
+package dedupe
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps keys in memory. Keys only live as long as the Lambda
+// instance, so it is intended for local runs and tests.
+type MemoryStore struct {
+	mu   sync.Mutex
+	keys map[string]time.Time
+	now  func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	// drop expired keys so long-lived instances do not grow unbounded
+	for k, expiresAt := range s.keys {
+		if now.After(expiresAt) {
+			delete(s.keys, k)
+		}
+	}
+
+	if _, ok := s.keys[key]; ok {
+		return false, nil
+	}
+	s.keys[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
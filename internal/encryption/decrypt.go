@@ -11,9 +11,17 @@ import (
 	"github.com/chainifynet/aws-encryption-sdk-go/pkg/materials"
 	"github.com/chainifynet/aws-encryption-sdk-go/pkg/providers/kmsprovider"
 	"github.com/chainifynet/aws-encryption-sdk-go/pkg/suite"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
 )
 
 func Decrypt(ctx context.Context, kmsId, encryptedText string) (string, error) {
+	ctx, span := tracing.Start(ctx, "encryption.Decrypt")
+	plaintext, err := decrypt(ctx, kmsId, encryptedText)
+	tracing.End(span, err)
+	return plaintext, err
+}
+
+func decrypt(ctx context.Context, kmsId, encryptedText string) (string, error) {
 	// mock the decryption for testing - only allowed in debug mode
 	if os.Getenv("APP_DEBUG_MODE") == "true" && kmsId == "MOCKED_KEY_ID" {
 		return encryptedText, nil
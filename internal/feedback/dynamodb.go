@@ -0,0 +1,58 @@
+package feedback
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBSink.
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoDBSink writes feedback events to a DynamoDB table with a string
+// partition key "pk" of "<provider>#<message id>" and a string sort key "sk"
+// of "<timestamp>#<type>#<address>", so the outcomes of a message can be
+// queried with the provider and message ID of its audit record. A redelivered
+// event overwrites itself.
+type DynamoDBSink struct {
+	Client DynamoDBAPI
+	Table  string
+}
+
+func NewDynamoDBSink(cfg *config.Config) *DynamoDBSink {
+	return &DynamoDBSink{
+		Client: dynamodb.NewFromConfig(*cfg.AWSConfig),
+		Table:  cfg.AppFeedbackTable,
+	}
+}
+
+func (s *DynamoDBSink) Write(ctx context.Context, e Event) error {
+	item := map[string]ddbtypes.AttributeValue{
+		"pk":        &ddbtypes.AttributeValueMemberS{Value: e.Provider + "#" + e.MessageID},
+		"sk":        &ddbtypes.AttributeValueMemberS{Value: e.Timestamp.UTC().Format(time.RFC3339Nano) + "#" + e.Type + "#" + strings.ToLower(e.Address)},
+		"type":      &ddbtypes.AttributeValueMemberS{Value: e.Type},
+		"permanent": &ddbtypes.AttributeValueMemberBOOL{Value: e.Permanent},
+		"timestamp": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(e.Timestamp.Unix(), 10)},
+	}
+	if e.Reason != "" {
+		item["reason"] = &ddbtypes.AttributeValueMemberS{Value: e.Reason}
+	}
+
+	_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: awssdk.String(s.Table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing feedback event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package feedback ingests delivery, bounce and complaint events from email
+// providers, stores them by provider message ID and feeds them into the
+// suppression store.
+package feedback
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
+)
+
+// Event types.
+const (
+	TypeBounce    = "bounce"
+	TypeComplaint = "complaint"
+	TypeDelivery  = "delivery"
+)
+
+// Event is a provider feedback event for a single recipient.
+type Event struct {
+	Type      string
+	Provider  string
+	Address   string
+	Permanent bool // bounces only; transient bounces are not suppressed
+	Reason    string
+	MessageID string
+	Timestamp time.Time
+}
+
+// Suppressed reports whether the recipient of e should no longer be sent to.
+func (e Event) Suppressed() bool {
+	return e.Type == TypeComplaint || e.Type == TypeBounce && e.Permanent
+}
+
+// Store records suppressed addresses.
+type Store interface {
+	Add(ctx context.Context, address string, e suppression.Entry) error
+}
+
+// Sink stores every feedback event so it can be joined with the audit record
+// of its message.
+type Sink interface {
+	Write(ctx context.Context, e Event) error
+}
+
+// Recorder writes feedback events to a Sink and suppressed recipients to a
+// Store.
+type Recorder struct {
+	Store Store
+	Sink  Sink
+}
+
+// Record writes every event to the sink and adds the recipients of permanent
+// bounces and complaints to the store.
+func (r *Recorder) Record(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		if r.Sink != nil {
+			if err := r.Sink.Write(ctx, e); err != nil {
+				return fmt.Errorf("error recording %s from %s: %w", e.Type, e.Provider, err)
+			}
+		}
+		slog.DebugContext(ctx, "feedback event recorded",
+			"type", e.Type,
+			"provider", e.Provider,
+			"message_id", e.MessageID,
+		)
+		if !e.Suppressed() {
+			continue
+		}
+
+		reason := suppression.ReasonBounce
+		if e.Type == TypeComplaint {
+			reason = suppression.ReasonComplaint
+		}
+		err := r.Store.Add(ctx, e.Address, suppression.Entry{
+			Reason:    reason,
+			Detail:    e.Reason,
+			CreatedAt: e.Timestamp,
+		})
+		if err != nil {
+			return fmt.Errorf("error recording %s from %s: %w", e.Type, e.Provider, err)
+		}
+		slog.InfoContext(ctx, "address suppressed from feedback",
+			"type", e.Type,
+			"provider", e.Provider,
+			"message_id", e.MessageID,
+		)
+	}
+	return nil
+}
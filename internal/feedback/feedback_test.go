@@ -0,0 +1,268 @@
+package feedback
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
+)
+
+const sesBounce = `{
+  "notificationType": "Bounce",
+  "mail": {"messageId": "ses-1"},
+  "bounce": {
+    "bounceType": "Permanent",
+    "timestamp": "2025-01-02T03:04:05Z",
+    "bouncedRecipients": [{"emailAddress": "gone@example.com", "diagnosticCode": "smtp; 550 user unknown"}]
+  }
+}`
+
+type fakeStore struct {
+	added map[string]suppression.Entry
+	err   error
+}
+
+func (f *fakeStore) Add(ctx context.Context, address string, e suppression.Entry) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.added[address] = e
+	return nil
+}
+
+type fakeSink struct {
+	written []Event
+	err     error
+}
+
+func (f *fakeSink) Write(ctx context.Context, e Event) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.written = append(f.written, e)
+	return nil
+}
+
+func TestParseSESNotification(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		wantType  string
+		wantAddr  string
+		suppress  bool
+		wantCount int
+	}{
+		{"permanent bounce", sesBounce, TypeBounce, "gone@example.com", true, 1},
+		{
+			"transient bounce",
+			`{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bouncedRecipients":[{"emailAddress":"full@example.com"}]}}`,
+			TypeBounce, "full@example.com", false, 1,
+		},
+		{
+			"complaint event",
+			`{"eventType":"Complaint","mail":{"messageId":"ses-2"},"complaint":{"complaintFeedbackType":"abuse","complainedRecipients":[{"emailAddress":"angry@example.com"}]}}`,
+			TypeComplaint, "angry@example.com", true, 1,
+		},
+		{
+			"delivery",
+			`{"notificationType":"Delivery","delivery":{"recipients":["a@example.com","b@example.com"]}}`,
+			TypeDelivery, "a@example.com", false, 2,
+		},
+		{"unsupported", `{"notificationType":"Open"}`, "", "", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSESNotification([]byte(tt.message))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("expected %d events, got %+v", tt.wantCount, got)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			if got[0].Type != tt.wantType || got[0].Address != tt.wantAddr || got[0].Suppressed() != tt.suppress {
+				t.Errorf("unexpected event: %+v", got[0])
+			}
+		})
+	}
+
+	if _, err := ParseSESNotification([]byte("not json")); err == nil {
+		t.Error("expected error for invalid notification")
+	}
+}
+
+func TestParseSendGridEvents(t *testing.T) {
+	body := `[
+	  {"email":"gone@example.com","event":"bounce","type":"bounce","reason":"550 user unknown","timestamp":1700000000,"sg_message_id":"sg-1.filterdrecv-1.0"},
+	  {"email":"blocked@example.com","event":"bounce","type":"blocked","timestamp":1700000000},
+	  {"email":"angry@example.com","event":"spamreport","timestamp":1700000000},
+	  {"email":"ok@example.com","event":"delivered","timestamp":1700000000},
+	  {"email":"ok@example.com","event":"open","timestamp":1700000000}
+	]`
+
+	got, err := ParseSendGridEvents([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("expected engagement events to be skipped, got %+v", got)
+	}
+	if !got[0].Suppressed() || got[0].Reason != "550 user unknown" || got[0].Timestamp.Unix() != 1700000000 || got[0].MessageID != "sg-1" {
+		t.Errorf("unexpected hard bounce: %+v", got[0])
+	}
+	if got[1].Suppressed() {
+		t.Errorf("expected blocked bounce not to suppress: %+v", got[1])
+	}
+	if got[2].Type != TypeComplaint || got[3].Type != TypeDelivery {
+		t.Errorf("unexpected event types: %+v", got[2:])
+	}
+}
+
+func TestRecorder_Record(t *testing.T) {
+	store := &fakeStore{added: map[string]suppression.Entry{}}
+	sink := &fakeSink{}
+	r := &Recorder{Store: store, Sink: sink}
+
+	events, _ := ParseSESNotification([]byte(sesBounce))
+	events = append(events, Event{Type: TypeDelivery, Provider: "ses", Address: "ok@example.com", MessageID: "ses-2"})
+	if err := r.Record(context.Background(), events); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.written) != 2 || sink.written[0].MessageID != "ses-1" || sink.written[1].Type != TypeDelivery {
+		t.Errorf("expected every event to be written to the sink, got %+v", sink.written)
+	}
+	if len(store.added) != 1 {
+		t.Fatalf("expected only the bounce to be stored, got %+v", store.added)
+	}
+	e := store.added["gone@example.com"]
+	if e.Reason != suppression.ReasonBounce || e.Detail != "smtp; 550 user unknown" || e.CreatedAt.IsZero() {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	store.err = errors.New("throttled")
+	if err := r.Record(context.Background(), events); err == nil {
+		t.Error("expected store error")
+	}
+
+	store.err = nil
+	sink.err = errors.New("throttled")
+	if err := r.Record(context.Background(), events); err == nil {
+		t.Error("expected sink error")
+	}
+}
+
+type fakeDynamoDB struct {
+	input *dynamodb.PutItemInput
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.input = in
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBSink_Write(t *testing.T) {
+	client := &fakeDynamoDB{}
+	s := &DynamoDBSink{Client: client, Table: "feedback"}
+
+	events, _ := ParseSESNotification([]byte(sesBounce))
+	if err := s.Write(context.Background(), events[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	item := client.input.Item
+	if pk := item["pk"].(*ddbtypes.AttributeValueMemberS).Value; pk != "ses#ses-1" {
+		t.Errorf("expected item keyed by provider and message id, got %q", pk)
+	}
+	if sk := item["sk"].(*ddbtypes.AttributeValueMemberS).Value; sk != "2025-01-02T03:04:05Z#bounce#gone@example.com" {
+		t.Errorf("unexpected sort key %q", sk)
+	}
+	if !item["permanent"].(*ddbtypes.AttributeValueMemberBOOL).Value {
+		t.Error("expected permanent bounce")
+	}
+}
+
+func TestHandler_SNS(t *testing.T) {
+	store := &fakeStore{added: map[string]suppression.Entry{}}
+	h := &Handler{Recorder: &Recorder{Store: store}}
+
+	raw, _ := json.Marshal(events.SNSEvent{Records: []events.SNSEventRecord{
+		{EventSource: "aws:sns", SNS: events.SNSEntity{MessageID: "1", Message: "not json"}},
+		{EventSource: "aws:sns", SNS: events.SNSEntity{MessageID: "2", Message: sesBounce}},
+	}})
+	if _, err := h.HandleEvent(context.Background(), raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.added["gone@example.com"]; !ok {
+		t.Errorf("expected bounce to be stored, got %+v", store.added)
+	}
+}
+
+func TestHandler_SendGridWebhook(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	pub, err := ParseSendGridPublicKey(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := `[{"email":"angry@example.com","event":"spamreport","timestamp":1700000000}]`
+	timestamp := "1700000000"
+	digest := sha256.Sum256([]byte(timestamp + body))
+	sig, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+
+	request := func(signature string) json.RawMessage {
+		raw, _ := json.Marshal(events.LambdaFunctionURLRequest{
+			Headers: map[string]string{
+				"x-twilio-email-event-webhook-signature": signature,
+				"x-twilio-email-event-webhook-timestamp": timestamp,
+			},
+			RequestContext: events.LambdaFunctionURLRequestContext{RequestID: "req-1"},
+			Body:           body,
+		})
+		return raw
+	}
+
+	store := &fakeStore{added: map[string]suppression.Entry{}}
+	h := &Handler{Recorder: &Recorder{Store: store}}
+
+	res, _ := h.HandleEvent(context.Background(), request(base64.StdEncoding.EncodeToString(sig)))
+	if res.(events.LambdaFunctionURLResponse).StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected requests to be rejected without a key, got %+v", res)
+	}
+
+	h.SendGridKey = pub
+	res, _ = h.HandleEvent(context.Background(), request("bm90IGEgc2lnbmF0dXJl"))
+	if res.(events.LambdaFunctionURLResponse).StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected invalid signature to be rejected, got %+v", res)
+	}
+	if len(store.added) != 0 {
+		t.Fatalf("expected nothing stored from rejected requests, got %+v", store.added)
+	}
+
+	res, _ = h.HandleEvent(context.Background(), request(base64.StdEncoding.EncodeToString(sig)))
+	if res.(events.LambdaFunctionURLResponse).StatusCode != http.StatusOK {
+		t.Errorf("expected signed request to be accepted, got %+v", res)
+	}
+	if e := store.added["angry@example.com"]; e.Reason != suppression.ReasonComplaint {
+		t.Errorf("expected complaint to be stored, got %+v", store.added)
+	}
+}
@@ -0,0 +1,128 @@
+package feedback
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+)
+
+// Handler handles the invocations of the feedback events Lambda.
+type Handler struct {
+	Recorder *Recorder
+
+	// SendGridKey verifies webhook requests; without it they are rejected
+	SendGridKey *ecdsa.PublicKey
+}
+
+func NewHandler(cfg *config.Config) (*Handler, error) {
+	h := &Handler{
+		Recorder: &Recorder{
+			Store: suppression.NewDynamoDBStore(cfg),
+			Sink:  NewDynamoDBSink(cfg),
+		},
+	}
+	if cfg.SendGridWebhookPublicKey != "" {
+		key, err := ParseSendGridPublicKey(cfg.SendGridWebhookPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		h.SendGridKey = key
+	}
+	return h, nil
+}
+
+// HandleEvent routes a raw Lambda event by its shape: SNS events carry SES
+// notifications and function URL requests carry SendGrid webhooks.
+func (h *Handler) HandleEvent(ctx context.Context, raw json.RawMessage) (any, error) {
+	var probe struct {
+		Records        []json.RawMessage `json:"Records"`
+		RequestContext json.RawMessage   `json:"requestContext"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse event: %w", err)
+	}
+
+	switch {
+	case len(probe.Records) > 0:
+		var event events.SNSEvent
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse sns event: %w", err)
+		}
+		return nil, h.HandleSNS(ctx, event)
+	case probe.RequestContext != nil:
+		var req events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, fmt.Errorf("failed to parse webhook request: %w", err)
+		}
+		return h.HandleSendGridWebhook(ctx, req), nil
+	default:
+		return nil, errors.New("unsupported event")
+	}
+}
+
+// HandleSNS records the SES notifications in an SNS event. An error makes
+// SNS retry the delivery.
+func (h *Handler) HandleSNS(ctx context.Context, event events.SNSEvent) error {
+	for _, record := range event.Records {
+		parsed, err := ParseSESNotification([]byte(record.SNS.Message))
+		if err != nil {
+			slog.WarnContext(ctx, "skipping invalid ses notification", "sns_message_id", record.SNS.MessageID, "error", err)
+			continue
+		}
+		if err := h.Recorder.Record(ctx, parsed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleSendGridWebhook verifies and records a SendGrid event webhook
+// request. Failures to record return a 5xx so SendGrid retries.
+func (h *Handler) HandleSendGridWebhook(ctx context.Context, req events.LambdaFunctionURLRequest) events.LambdaFunctionURLResponse {
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return webhookResponse(http.StatusBadRequest)
+		}
+		body = decoded
+	}
+
+	if h.SendGridKey == nil {
+		slog.WarnContext(ctx, "sendgrid webhook rejected, no public key configured")
+		return webhookResponse(http.StatusUnauthorized)
+	}
+	// function urls lowercase header names
+	signature := req.Headers[strings.ToLower(eventwebhook.VerificationHTTPHeader)]
+	timestamp := req.Headers[strings.ToLower(eventwebhook.TimestampHTTPHeader)]
+	if err := VerifySendGridSignature(h.SendGridKey, body, signature, timestamp); err != nil {
+		slog.WarnContext(ctx, "sendgrid webhook rejected", "error", err)
+		return webhookResponse(http.StatusUnauthorized)
+	}
+
+	parsed, err := ParseSendGridEvents(body)
+	if err != nil {
+		slog.WarnContext(ctx, "invalid sendgrid webhook body", "error", err)
+		return webhookResponse(http.StatusBadRequest)
+	}
+	if err := h.Recorder.Record(ctx, parsed); err != nil {
+		slog.ErrorContext(ctx, "failed to record sendgrid events", "error", err)
+		return webhookResponse(http.StatusInternalServerError)
+	}
+	return webhookResponse(http.StatusOK)
+}
+
+func webhookResponse(status int) events.LambdaFunctionURLResponse {
+	return events.LambdaFunctionURLResponse{StatusCode: status}
+}
@@ -0,0 +1,91 @@
+package feedback
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+)
+
+// ErrInvalidSignature is returned when a SendGrid webhook request is not
+// signed with the configured key.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+type sendGridEvent struct {
+	Email       string `json:"email"`
+	Event       string `json:"event"`
+	Type        string `json:"type"` // bounce or blocked
+	Reason      string `json:"reason"`
+	Timestamp   int64  `json:"timestamp"`
+	SGMessageID string `json:"sg_message_id"`
+}
+
+// ParseSendGridEvents parses the body of a SendGrid event webhook request.
+// Engagement events such as opens and clicks yield no events.
+func ParseSendGridEvents(body []byte) ([]Event, error) {
+	var raw []sendGridEvent
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("invalid sendgrid events: %w", err)
+	}
+
+	var events []Event
+	for _, r := range raw {
+		// sg_message_id is the X-Message-Id of the send followed by a
+		// per-recipient suffix
+		messageID, _, _ := strings.Cut(r.SGMessageID, ".")
+		e := Event{
+			Provider:  "sendgrid",
+			Address:   r.Email,
+			Reason:    r.Reason,
+			MessageID: messageID,
+			Timestamp: time.Unix(r.Timestamp, 0).UTC(),
+		}
+		switch r.Event {
+		case "bounce":
+			e.Type = TypeBounce
+			e.Permanent = r.Type != "blocked"
+		case "spamreport":
+			e.Type = TypeComplaint
+		case "delivered":
+			e.Type = TypeDelivery
+		default:
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// ParseSendGridPublicKey parses the base64 encoded public key of a SendGrid
+// signed event webhook.
+func ParseSendGridPublicKey(publicKey string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sendgrid webhook public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sendgrid webhook public key: %w", err)
+	}
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("invalid sendgrid webhook public key: not an ecdsa key")
+	}
+	return ecKey, nil
+}
+
+// VerifySendGridSignature checks the signature of a SendGrid signed event
+// webhook request.
+func VerifySendGridSignature(key *ecdsa.PublicKey, body []byte, signature, timestamp string) error {
+	ok, err := eventwebhook.VerifySignature(key, body, signature, timestamp)
+	if err != nil || !ok {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package feedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"` // configuration set event publishing
+	Mail             struct {
+		MessageID string `json:"messageId"`
+	} `json:"mail"`
+	Bounce *struct {
+		BounceType        string    `json:"bounceType"`
+		Timestamp         time.Time `json:"timestamp"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		ComplaintFeedbackType string    `json:"complaintFeedbackType"`
+		Timestamp             time.Time `json:"timestamp"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Delivery *struct {
+		Timestamp  time.Time `json:"timestamp"`
+		Recipients []string  `json:"recipients"`
+	} `json:"delivery"`
+}
+
+// ParseSESNotification parses an SES notification or configuration set event
+// delivered through SNS. Unsupported types yield no events.
+func ParseSESNotification(message []byte) ([]Event, error) {
+	var n sesNotification
+	if err := json.Unmarshal(message, &n); err != nil {
+		return nil, fmt.Errorf("invalid ses notification: %w", err)
+	}
+
+	kind := n.NotificationType
+	if kind == "" {
+		kind = n.EventType
+	}
+
+	var events []Event
+	switch {
+	case kind == "Bounce" && n.Bounce != nil:
+		for _, r := range n.Bounce.BouncedRecipients {
+			events = append(events, Event{
+				Type:      TypeBounce,
+				Provider:  "ses",
+				Address:   r.EmailAddress,
+				Permanent: n.Bounce.BounceType == "Permanent",
+				Reason:    r.DiagnosticCode,
+				MessageID: n.Mail.MessageID,
+				Timestamp: n.Bounce.Timestamp,
+			})
+		}
+	case kind == "Complaint" && n.Complaint != nil:
+		for _, r := range n.Complaint.ComplainedRecipients {
+			events = append(events, Event{
+				Type:      TypeComplaint,
+				Provider:  "ses",
+				Address:   r.EmailAddress,
+				Reason:    n.Complaint.ComplaintFeedbackType,
+				MessageID: n.Mail.MessageID,
+				Timestamp: n.Complaint.Timestamp,
+			})
+		}
+	case kind == "Delivery" && n.Delivery != nil:
+		for _, addr := range n.Delivery.Recipients {
+			events = append(events, Event{
+				Type:      TypeDelivery,
+				Provider:  "ses",
+				Address:   addr,
+				MessageID: n.Mail.MessageID,
+				Timestamp: n.Delivery.Timestamp,
+			})
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,188 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+const redacted = "[REDACTED]"
+
+// defaultRedactKeys are attribute and map keys whose values are always
+// replaced, such as the verification code merged into template data.
+var defaultRedactKeys = []string{
+	"code",
+	"verification_code",
+	"password",
+	"authorization",
+	"api_key",
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+[1-9][0-9]{6,14}`)
+)
+
+// RedactingHandler masks verification codes, email addresses, phone numbers
+// and configured keys before passing records to the wrapped handler.
+type RedactingHandler struct {
+	next       slog.Handler
+	keys       map[string]bool
+	keyPattern *regexp.Regexp
+}
+
+// NewHandler wraps next in a RedactingHandler unless redaction was disabled
+// for a local debug run.
+func NewHandler(next slog.Handler, cfg *config.Config) slog.Handler {
+	if cfg.AppLogRedactionDisabled {
+		return next
+	}
+	return NewRedactingHandler(next, cfg.AppLogRedactKeys...)
+}
+
+// NewRedactingHandler redacts the default keys plus keys. Keys are matched
+// case-insensitively.
+func NewRedactingHandler(next slog.Handler, keys ...string) *RedactingHandler {
+	h := &RedactingHandler{next: next, keys: make(map[string]bool)}
+
+	var quoted []string
+	for _, k := range append(defaultRedactKeys, keys...) {
+		k = strings.ToLower(k)
+		if h.keys[k] {
+			continue
+		}
+		h.keys[k] = true
+		quoted = append(quoted, regexp.QuoteMeta(k))
+	}
+
+	// matches "key":"value" pairs inside json strings such as raw events
+	h.keyPattern = regexp.MustCompile(`(?i)"(` + strings.Join(quoted, "|") + `)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+	return h
+}
+
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redactAttr(a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redactedAttrs), keys: h.keys, keyPattern: h.keyPattern}
+}
+
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), keys: h.keys, keyPattern: h.keyPattern}
+}
+
+func (h *RedactingHandler) redactAttr(a slog.Attr) slog.Attr {
+	if h.keys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	return slog.Attr{Key: a.Key, Value: h.redactValue(a.Value.Resolve())}
+}
+
+func (h *RedactingHandler) redactValue(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(h.RedactString(v.String()))
+	case slog.KindGroup:
+		attrs := v.Group()
+		out := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			out[i] = h.redactAttr(a)
+		}
+		return slog.GroupValue(out...)
+	case slog.KindAny:
+		return h.redactAny(v.Any())
+	default:
+		return v
+	}
+}
+
+func (h *RedactingHandler) redactAny(v any) slog.Value {
+	switch x := v.(type) {
+	case nil:
+		return slog.AnyValue(nil)
+	case error:
+		return slog.StringValue(h.RedactString(x.Error()))
+	case []byte:
+		return slog.StringValue(h.RedactString(string(x)))
+	}
+
+	// structs, maps and slices are redacted through their json form since
+	// that is how the json handler would render them anyway
+	b, err := json.Marshal(v)
+	if err != nil {
+		return slog.StringValue(redacted)
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return slog.StringValue(redacted)
+	}
+	return slog.AnyValue(h.redactJSON(generic))
+}
+
+func (h *RedactingHandler) redactJSON(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, val := range x {
+			if h.keys[strings.ToLower(k)] {
+				x[k] = redacted
+				continue
+			}
+			x[k] = h.redactJSON(val)
+		}
+		return x
+	case []any:
+		for i, val := range x {
+			x[i] = h.redactJSON(val)
+		}
+		return x
+	case string:
+		return h.RedactString(x)
+	default:
+		return v
+	}
+}
+
+// RedactString masks redacted keys in embedded json, email addresses and
+// phone numbers in s. Emails keep their first character and domain, and
+// phone numbers their last four digits, so logs stay useful for debugging.
+func (h *RedactingHandler) RedactString(s string) string {
+	s = h.keyPattern.ReplaceAllString(s, `"$1":"`+redacted+`"`)
+	s = emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+	s = phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+	return s
+}
+
+// MaskEmail masks the local part of an email address.
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// MaskPhone masks all but the last four digits of a phone number.
+func MaskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return redacted
+	}
+	return "***" + phone[len(phone)-4:]
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+func newTestLogger(keys ...string) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := NewRedactingHandler(slog.NewJSONHandler(&buf, nil), keys...)
+	return slog.New(h), &buf
+}
+
+func TestRedactingHandler_Attrs(t *testing.T) {
+	logger, buf := newTestLogger("ip")
+
+	logger.Info("dry-run send",
+		"template_data", map[string]any{"code": "123456", "name": "Jane"},
+		"dst_address", "jane.doe@example.com",
+		"dst_phone_number", "+15555550123",
+		"ip", "203.0.113.7",
+		"error", errors.New("rejected recipient jane.doe@example.com"),
+		slog.Group("request", "Code", "654321"),
+	)
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, leaked := range []string{"123456", "654321", "jane.doe", "5555550123", "203.0.113.7"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("expected %q to be redacted, got: %s", leaked, out)
+		}
+	}
+
+	if got["dst_address"] != "j***@example.com" {
+		t.Errorf("unexpected masked email: %v", got["dst_address"])
+	}
+	if got["dst_phone_number"] != "***0123" {
+		t.Errorf("unexpected masked phone number: %v", got["dst_phone_number"])
+	}
+	if data := got["template_data"].(map[string]any); data["name"] != "Jane" {
+		t.Errorf("expected non-sensitive template data to be kept, got: %v", data)
+	}
+}
+
+func TestRedactingHandler_RawEvent(t *testing.T) {
+	logger, buf := newTestLogger()
+
+	event := `{"request":{"code":"AYADeHZ\"x","userAttributes":{"email":"user@example.com","phone_number":"+447700900123"}}}`
+	logger.With("source", "lambda").Debug("received event", "event", event)
+	logger.Info("received event", "event", event)
+
+	out := buf.String()
+	if strings.Contains(out, "AYADeHZ") || strings.Contains(out, "user@example.com") || strings.Contains(out, "7700900123") {
+		t.Errorf("expected raw event to be redacted, got: %s", out)
+	}
+	if !strings.Contains(out, `\"code\":\"[REDACTED]\"`) {
+		t.Errorf("expected code to be replaced, got: %s", out)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	next := slog.NewJSONHandler(&bytes.Buffer{}, nil)
+
+	if _, ok := NewHandler(next, &config.Config{}).(*RedactingHandler); !ok {
+		t.Error("expected redaction by default")
+	}
+	if NewHandler(next, &config.Config{AppLogRedactionDisabled: true}) != next {
+		t.Error("expected opt-out to return the wrapped handler")
+	}
+}
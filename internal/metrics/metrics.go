@@ -0,0 +1,275 @@
+// Package metrics emits CloudWatch Embedded Metric Format (EMF) metrics. Points
+// are collected in a per-invocation Scope carried by the context and written
+// to stdout as EMF log lines when the invocation ends.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// Units of metric values.
+const (
+	UnitCount        = "Count"
+	UnitMilliseconds = "Milliseconds"
+)
+
+// Dimension is a metric dimension.
+type Dimension struct {
+	Name  string
+	Value string
+}
+
+// Dim returns a Dimension.
+func Dim(name, value string) Dimension {
+	return Dimension{Name: name, Value: value}
+}
+
+// Emitter writes EMF documents.
+type Emitter struct {
+	Writer    io.Writer
+	Namespace string
+	now       func() time.Time
+}
+
+// NewEmitter creates an Emitter writing to stdout. It returns nil if metrics
+// are disabled.
+func NewEmitter(cfg *config.Config) *Emitter {
+	if !cfg.AppMetricsEnabled {
+		return nil
+	}
+	return &Emitter{Writer: os.Stdout, Namespace: cfg.AppMetricsNamespace}
+}
+
+// NewScope starts collecting the metrics of one invocation. Every metric is
+// reported with the user pool and client dimensions. It returns nil if e is
+// nil, which makes recording a no-op.
+func (e *Emitter) NewScope(userPoolID, clientID, trigger string) *Scope {
+	if e == nil {
+		return nil
+	}
+	s := &Scope{emitter: e, trigger: trigger}
+	if userPoolID != "" {
+		s.dims = append(s.dims, Dim("UserPoolId", userPoolID))
+	}
+	if clientID != "" {
+		s.dims = append(s.dims, Dim("ClientId", clientID))
+	}
+	return s
+}
+
+// Scope collects the metric points of one invocation.
+type Scope struct {
+	emitter *Emitter
+	dims    []Dimension
+	trigger string
+
+	mu     sync.Mutex
+	points []point
+	props  map[string]any
+}
+
+type point struct {
+	name  string
+	unit  string
+	value float64
+	dims  []Dimension
+}
+
+type scopeKey struct{}
+
+// NewContext returns a context carrying s.
+func NewContext(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// FromContext returns the Scope in ctx, or nil.
+func FromContext(ctx context.Context) *Scope {
+	s, _ := ctx.Value(scopeKey{}).(*Scope)
+	return s
+}
+
+// Trigger returns the trigger source of the invocation in ctx.
+func Trigger(ctx context.Context) string {
+	if s := FromContext(ctx); s != nil {
+		return s.trigger
+	}
+	return ""
+}
+
+// Count adds one to the named count metric of the invocation in ctx.
+func Count(ctx context.Context, name string, dims ...Dimension) {
+	FromContext(ctx).add(point{name: name, unit: UnitCount, value: 1, dims: dims})
+}
+
+// Duration records d in milliseconds for the invocation in ctx.
+func Duration(ctx context.Context, name string, d time.Duration, dims ...Dimension) {
+	ms := float64(d) / float64(time.Millisecond)
+	FromContext(ctx).add(point{name: name, unit: UnitMilliseconds, value: ms, dims: dims})
+}
+
+// Property attaches a value to the documents of the invocation in ctx without
+// making it a dimension, e.g. to find the metrics of a message in the logs.
+func Property(ctx context.Context, name string, value any) {
+	s := FromContext(ctx)
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.props == nil {
+		s.props = make(map[string]any)
+	}
+	s.props[name] = value
+}
+
+func (s *Scope) add(p point) {
+	if s == nil {
+		return
+	}
+	// cloudwatch rejects empty dimension values
+	for i, d := range p.dims {
+		if d.Value == "" {
+			p.dims[i].Value = "unknown"
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.points = append(s.points, p)
+}
+
+// Flush writes one EMF document per distinct set of dimensions and clears the
+// collected points. Counts are summed; durations are written as value arrays.
+func (s *Scope) Flush() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	points, props := s.points, s.props
+	s.points, s.props = nil, nil
+	s.mu.Unlock()
+
+	now := time.Now
+	if s.emitter.now != nil {
+		now = s.emitter.now
+	}
+	ts := now().UnixMilli()
+
+	for _, g := range groupPoints(points) {
+		doc, err := s.document(g, props, ts)
+		if err != nil {
+			return err
+		}
+		if _, err := s.emitter.Writer.Write(append(doc, '\n')); err != nil {
+			return fmt.Errorf("error writing metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+// group is the points sharing one set of dimensions, in first-seen order.
+type group struct {
+	dims   []Dimension
+	names  []string
+	units  map[string]string
+	values map[string][]float64
+}
+
+func groupPoints(points []point) []*group {
+	var groups []*group
+	byKey := make(map[string]*group)
+	for _, p := range points {
+		key := dimensionKey(p.dims)
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{dims: p.dims, units: map[string]string{}, values: map[string][]float64{}}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		if _, ok := g.values[p.name]; !ok {
+			g.names = append(g.names, p.name)
+			g.units[p.name] = p.unit
+		}
+		if p.unit == UnitCount && len(g.values[p.name]) > 0 {
+			g.values[p.name][0] += p.value
+			continue
+		}
+		g.values[p.name] = append(g.values[p.name], p.value)
+	}
+	return groups
+}
+
+func dimensionKey(dims []Dimension) string {
+	parts := make([]string, len(dims))
+	for i, d := range dims {
+		parts[i] = d.Name + "=" + d.Value
+	}
+	return strings.Join(parts, "\x00")
+}
+
+type emfMetadata struct {
+	Timestamp         int64                `json:"Timestamp"`
+	CloudWatchMetrics []emfMetricDirective `json:"CloudWatchMetrics"`
+}
+
+type emfMetricDirective struct {
+	Namespace  string          `json:"Namespace"`
+	Dimensions [][]string      `json:"Dimensions"`
+	Metrics    []emfMetricInfo `json:"Metrics"`
+}
+
+type emfMetricInfo struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// document builds the EMF document of g. Metrics are reported both with the
+// invocation dimensions and with the metric's own dimensions alone, so alarms
+// can aggregate across user pools and clients.
+func (s *Scope) document(g *group, props map[string]any, ts int64) ([]byte, error) {
+	doc := map[string]any{}
+	for k, v := range props {
+		doc[k] = v
+	}
+
+	all, own := []string{}, []string{}
+	for _, d := range s.dims {
+		all = append(all, d.Name)
+		doc[d.Name] = d.Value
+	}
+	for _, d := range g.dims {
+		all = append(all, d.Name)
+		own = append(own, d.Name)
+		doc[d.Name] = d.Value
+	}
+	dimensionSets := [][]string{all}
+	if len(s.dims) > 0 {
+		dimensionSets = append(dimensionSets, own)
+	}
+
+	directive := emfMetricDirective{Namespace: s.emitter.Namespace, Dimensions: dimensionSets}
+	for _, name := range g.names {
+		directive.Metrics = append(directive.Metrics, emfMetricInfo{Name: name, Unit: g.units[name]})
+		if v := g.values[name]; len(v) == 1 {
+			doc[name] = v[0]
+		} else {
+			doc[name] = v
+		}
+	}
+	doc["_aws"] = emfMetadata{Timestamp: ts, CloudWatchMetrics: []emfMetricDirective{directive}}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding metrics: %w", err)
+	}
+	return b, nil
+}
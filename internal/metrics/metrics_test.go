@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func decodeDocs(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var docs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			t.Fatalf("invalid emf line %q: %v", line, err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func TestScope_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	e := &Emitter{Writer: &buf, Namespace: "Test", now: func() time.Time { return time.UnixMilli(1700000000000) }}
+	ctx := NewContext(context.Background(), e.NewScope("us-east-1_abc", "client-1", "CustomEmailSender_SignUp"))
+
+	if Trigger(ctx) != "CustomEmailSender_SignUp" {
+		t.Errorf("unexpected trigger: %s", Trigger(ctx))
+	}
+
+	Count(ctx, "FailoverHops", Dim("Provider", "ses"))
+	Duration(ctx, "ProviderLatency", 120*time.Millisecond, Dim("Provider", "ses"))
+	Count(ctx, "FailoverHops", Dim("Provider", "ses"))
+	Duration(ctx, "ProviderLatency", 80*time.Millisecond, Dim("Provider", "ses"))
+	Count(ctx, "PolicyDenials", Dim("Reason", ""))
+	Duration(ctx, "KMSDecryptLatency", 5*time.Millisecond)
+	Property(ctx, "MessageId", "msg-1")
+
+	if err := FromContext(ctx).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	docs := decodeDocs(t, buf.String())
+	if len(docs) != 3 {
+		t.Fatalf("expected one document per dimension set, got %d: %s", len(docs), buf.String())
+	}
+
+	ses := docs[0]
+	if ses["UserPoolId"] != "us-east-1_abc" || ses["ClientId"] != "client-1" || ses["Provider"] != "ses" {
+		t.Errorf("unexpected dimensions: %v", ses)
+	}
+	if ses["MessageId"] != "msg-1" {
+		t.Errorf("expected property on every document, got %v", ses["MessageId"])
+	}
+	if ses["FailoverHops"] != 2.0 {
+		t.Errorf("expected counts to be summed, got %v", ses["FailoverHops"])
+	}
+	if latency, ok := ses["ProviderLatency"].([]any); !ok || len(latency) != 2 || latency[0] != 120.0 {
+		t.Errorf("expected latency values array, got %v", ses["ProviderLatency"])
+	}
+
+	meta := ses["_aws"].(map[string]any)
+	if meta["Timestamp"] != 1700000000000.0 {
+		t.Errorf("unexpected timestamp: %v", meta["Timestamp"])
+	}
+	directive := meta["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	if directive["Namespace"] != "Test" {
+		t.Errorf("unexpected namespace: %v", directive["Namespace"])
+	}
+	dims, _ := json.Marshal(directive["Dimensions"])
+	if string(dims) != `[["UserPoolId","ClientId","Provider"],["Provider"]]` {
+		t.Errorf("unexpected dimension sets: %s", dims)
+	}
+
+	if docs[1]["Reason"] != "unknown" {
+		t.Errorf("expected empty dimension value to be replaced, got %v", docs[1]["Reason"])
+	}
+	kmsDims, _ := json.Marshal(docs[2]["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)["Dimensions"])
+	if string(kmsDims) != `[["UserPoolId","ClientId"],[]]` {
+		t.Errorf("unexpected dimension sets without metric dimensions: %s", kmsDims)
+	}
+
+	buf.Reset()
+	FromContext(ctx).Flush()
+	if buf.Len() != 0 {
+		t.Errorf("expected flushed points to be cleared, got %s", buf.String())
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var e *Emitter
+	s := e.NewScope("pool", "client", "trigger")
+	ctx := NewContext(context.Background(), s)
+
+	// recording without a scope must be a no-op
+	Count(ctx, "Sends")
+	Count(context.Background(), "Sends")
+	Property(ctx, "MessageId", "msg-1")
+	if err := s.Flush(); err != nil {
+		t.Errorf("expected nil scope flush to succeed, got: %v", err)
+	}
+}
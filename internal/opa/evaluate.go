@@ -5,15 +5,55 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/decisionlog"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
 	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/bundle"
+	"github.com/open-policy-agent/opa/v1/loader"
 	"github.com/open-policy-agent/opa/v1/rego"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// PreparedPolicy holds a compiled policy ready for evaluation
+// PreparedPolicy holds a compiled policy ready for evaluation. The compiled
+// query can be replaced atomically while evaluations are in flight.
 type PreparedPolicy struct {
-	query rego.PreparedEvalQuery
+	state  atomic.Pointer[policyState]
+	logger *decisionlog.Logger
+}
+
+type policyState struct {
+	prepared rego.PreparedEvalQuery
+	query    string
+	revision string
+}
+
+func newPreparedPolicy(pq rego.PreparedEvalQuery, query, revision string) *PreparedPolicy {
+	pp := &PreparedPolicy{}
+	pp.state.Store(&policyState{prepared: pq, query: query, revision: revision})
+	return pp
+}
+
+// SetDecisionLogger enables decision logging for every evaluation of the
+// policy. It must be called before the policy is used concurrently.
+func (pp *PreparedPolicy) SetDecisionLogger(l *decisionlog.Logger) {
+	pp.logger = l
+}
+
+// Revision returns the bundle revision of the active policy, if any.
+func (pp *PreparedPolicy) Revision() string {
+	return pp.state.Load().revision
+}
+
+// Swap atomically replaces the active policy with the one from next.
+func (pp *PreparedPolicy) Swap(next *PreparedPolicy) {
+	pp.state.Store(next.state.Load())
 }
 
 // PreparePolicy compiles a policy and query for later evaluation.
@@ -30,20 +70,82 @@ func PreparePolicy(ctx context.Context, policy string, query string) (*PreparedP
 		return nil, fmt.Errorf("failed to prepare policy: %w", err)
 	}
 
-	return &PreparedPolicy{query: pq}, nil
+	return newPreparedPolicy(pq, query, ""), nil
+}
+
+// PreparePolicyDir compiles every Rego module in dir, except _test.rego files,
+// with the JSON and YAML files in dir loaded as data. Data files at the root of
+// dir are merged into data; files in subdirectories are nested under the
+// subdirectory path (e.g. tenants/data.yaml is loaded under data.tenants).
+func PreparePolicyDir(ctx context.Context, dir string, query string) (*PreparedPolicy, error) {
+	r := rego.New(
+		rego.Query(query),
+		rego.Load([]string{dir}, skipTestFiles),
+		rego.SetRegoVersion(ast.RegoV1),
+	)
+
+	pq, err := r.PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare policy directory: %w", err)
+	}
+
+	return newPreparedPolicy(pq, query, ""), nil
+}
+
+// skipTestFiles is a loader filter that excludes rego unit tests.
+var skipTestFiles loader.Filter = func(_ string, info fs.FileInfo, _ int) bool {
+	return !info.IsDir() && strings.HasSuffix(info.Name(), "_test.rego")
+}
+
+// PrepareBundle compiles the modules and data documents of an OPA bundle and
+// the query for later evaluation.
+func PrepareBundle(ctx context.Context, b *bundle.Bundle, query string) (*PreparedPolicy, error) {
+	r := rego.New(
+		rego.Query(query),
+		rego.ParsedBundle("policy", b),
+		rego.SetRegoVersion(ast.RegoV1),
+	)
+
+	pq, err := r.PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare policy bundle: %w", err)
+	}
+
+	return newPreparedPolicy(pq, query, b.Manifest.Revision), nil
+}
+
+// ReadBundle reads a gzipped tarball OPA bundle.
+func ReadBundle(r io.Reader) (*bundle.Bundle, error) {
+	b, err := bundle.NewReader(r).WithRegoVersion(ast.RegoV1).Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy bundle: %w", err)
+	}
+	return &b, nil
 }
 
 // Evaluate runs the prepared policy against the given input and returns the result as type T
 func Evaluate[T any](ctx context.Context, pp *PreparedPolicy, input any) (*T, error) {
-	rs, err := pp.query.Eval(ctx, rego.EvalInput(input))
+	state := pp.state.Load()
+
+	ctx, span := tracing.Start(ctx, "opa.Evaluate",
+		attribute.String("opa.query", state.query),
+		attribute.String("opa.revision", state.revision),
+	)
+	start := time.Now()
+	raw, err := state.eval(ctx, input)
+	tracing.End(span, err)
+	pp.logger.Log(ctx, &decisionlog.Decision{
+		Query:    state.query,
+		Revision: state.revision,
+		Input:    input,
+		Result:   raw,
+		Err:      err,
+		Latency:  time.Since(start),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to evaluate policy: %w", err)
-	}
-	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
-		return nil, fmt.Errorf("no results found during policy evaluation")
+		return nil, err
 	}
 
-	raw := rs[0].Expressions[0].Value
 	bs, err := json.Marshal(raw)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal policy result: %w", err)
@@ -57,6 +159,17 @@ func Evaluate[T any](ctx context.Context, pp *PreparedPolicy, input any) (*T, er
 	return &out, nil
 }
 
+func (s *policyState) eval(ctx context.Context, input any) (any, error) {
+	rs, err := s.prepared.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return nil, fmt.Errorf("no results found during policy evaluation")
+	}
+	return rs[0].Expressions[0].Value, nil
+}
+
 // EvaluatePolicy compiles policy and evaluates a query against input
 // - returns *T where T matches the rego result shape
 // - enforces rego v1 semantics during compilation
@@ -3,6 +3,7 @@ package opa
 import (
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -69,3 +70,48 @@ func TestEvaluatePolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestPreparePolicyDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"policy.rego": `
+			package mock_policy
+			result := {
+				"isAdmin": input.user in data.admins,
+				"template": data.tenants.templates[input.tenant]
+			}
+		`,
+		"admins.json":               `{"admins": ["admin"]}`,
+		"tenants/data.yaml":         "templates:\n  acme: acme-welcome\n",
+		"policy_test.rego":          "package mock_policy\nresult := \"shadowed\"\n",
+		"tenants/ignored_test.rego": "not valid rego",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pp, err := PreparePolicyDir(context.Background(), dir, "data.mock_policy.result")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	out, err := Evaluate[struct {
+		IsAdmin  bool   `json:"isAdmin"`
+		Template string `json:"template"`
+	}](context.Background(), pp, map[string]any{"user": "admin", "tenant": "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !out.IsAdmin {
+		t.Error("expected admin from json data")
+	}
+	if out.Template != "acme-welcome" {
+		t.Errorf("expected template from nested yaml data, got %q", out.Template)
+	}
+}
@@ -0,0 +1,125 @@
+package opa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// bundleFetchTimeout bounds a bundle download, which runs synchronously in an
+// invocation on cold start and on refresh.
+const bundleFetchTimeout = 10 * time.Second
+
+// Reloader keeps a PreparedPolicy in sync with a bundle source. Lambda freezes
+// background work between invocations, so refreshes are checked lazily on use
+// via MaybeRefresh rather than on a ticker.
+type Reloader struct {
+	source   BundleSource
+	policy   *PreparedPolicy
+	query    string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	etag      string
+	lastCheck time.Time
+}
+
+// LoadBundle fetches and prepares the bundle from source and returns the
+// policy together with a reloader that refreshes it every interval.
+func LoadBundle(ctx context.Context, source BundleSource, query string, interval time.Duration) (*PreparedPolicy, *Reloader, error) {
+	r := &Reloader{
+		source:   source,
+		query:    query,
+		interval: interval,
+		now:      time.Now,
+	}
+
+	pp, etag, err := r.fetch(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.policy = pp
+	r.etag = etag
+	r.lastCheck = r.now()
+
+	return pp, r, nil
+}
+
+// Policy returns the policy kept up to date by the reloader.
+func (r *Reloader) Policy() *PreparedPolicy {
+	return r.policy
+}
+
+// Refresh fetches the bundle if its ETag changed and atomically swaps the
+// policy. On error the current policy stays active.
+func (r *Reloader) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.refresh(ctx)
+}
+
+// MaybeRefresh refreshes the policy if the refresh interval has elapsed since
+// the last check. Errors are logged and the current policy is kept.
+func (r *Reloader) MaybeRefresh(ctx context.Context) {
+	if r.interval <= 0 || !r.mu.TryLock() {
+		return
+	}
+	defer r.mu.Unlock()
+
+	if r.now().Sub(r.lastCheck) < r.interval {
+		return
+	}
+
+	if err := r.refresh(ctx); err != nil {
+		slog.WarnContext(ctx, "policy refresh failed, keeping current policy",
+			"revision", r.policy.Revision(),
+			"error", err,
+		)
+	}
+}
+
+func (r *Reloader) refresh(ctx context.Context) error {
+	r.lastCheck = r.now()
+
+	next, etag, err := r.fetch(ctx, r.etag)
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r.policy.Swap(next)
+	r.etag = etag
+
+	slog.InfoContext(ctx, "policy bundle reloaded", "revision", next.Revision(), "etag", etag)
+	return nil
+}
+
+func (r *Reloader) fetch(ctx context.Context, etag string) (*PreparedPolicy, string, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, bundleFetchTimeout)
+	defer cancel()
+
+	body, newETag, err := r.source.Fetch(fetchCtx, etag)
+	if err != nil {
+		return nil, "", err
+	}
+	defer body.Close()
+
+	b, err := ReadBundle(body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pp, err := PrepareBundle(ctx, b, r.query)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to prepare bundle: %w", err)
+	}
+
+	return pp, newETag, nil
+}
@@ -0,0 +1,178 @@
+package opa
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testBundlePolicy = `
+package mock_policy
+result := {
+	"template": data.templates[input.trigger]
+}
+`
+
+type testBundleOutput struct {
+	Template string `json:"template"`
+}
+
+// buildTestBundle returns a gzipped tarball bundle with the given revision and
+// template map data.
+func buildTestBundle(t *testing.T, revision, template string) []byte {
+	t.Helper()
+
+	files := map[string]string{
+		"/.manifest":   fmt.Sprintf(`{"revision":%q}`, revision),
+		"/policy.rego": testBundlePolicy,
+		"/data.json":   fmt.Sprintf(`{"templates":{"signup":%q}}`, template),
+	}
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// bundleServer is a minimal bundle server that honors If-None-Match.
+type bundleServer struct {
+	mu       sync.Mutex
+	body     []byte
+	etag     string
+	requests int
+}
+
+func (s *bundleServer) set(body []byte, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.etag = etag
+}
+
+func (s *bundleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+
+	if r.Header.Get("If-None-Match") == s.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	w.Write(s.body)
+}
+
+func TestHTTPBundleSource_NotModified(t *testing.T) {
+	srv := &bundleServer{}
+	srv.set([]byte("bundle"), `"v1"`)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	source := &HTTPBundleSource{URL: ts.URL}
+
+	body, etag, err := source.Fetch(context.Background(), "")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	body.Close()
+	if etag != `"v1"` {
+		t.Errorf("expected etag %q, got %q", `"v1"`, etag)
+	}
+
+	_, _, err = source.Fetch(context.Background(), etag)
+	if !errors.Is(err, ErrNotModified) {
+		t.Errorf("expected ErrNotModified, got: %v", err)
+	}
+}
+
+func TestHTTPBundleSource_ErrorStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	source := &HTTPBundleSource{URL: ts.URL}
+	if _, _, err := source.Fetch(context.Background(), ""); err == nil {
+		t.Fatal("expected error for forbidden response")
+	}
+}
+
+func TestReloader_SwapsOnChange(t *testing.T) {
+	ctx := context.Background()
+
+	srv := &bundleServer{}
+	srv.set(buildTestBundle(t, "r1", "welcome-v1"), `"r1"`)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	pp, reloader, err := LoadBundle(ctx, &HTTPBundleSource{URL: ts.URL}, "data.mock_policy.result", time.Minute)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if pp.Revision() != "r1" {
+		t.Errorf("expected revision r1, got %q", pp.Revision())
+	}
+
+	now := time.Now()
+	reloader.now = func() time.Time { return now }
+
+	// within the interval nothing is fetched
+	reloader.MaybeRefresh(ctx)
+	if srv.requests != 1 {
+		t.Errorf("expected 1 request, got %d", srv.requests)
+	}
+
+	// unchanged bundle keeps the policy
+	now = now.Add(time.Minute)
+	reloader.MaybeRefresh(ctx)
+	if srv.requests != 2 || pp.Revision() != "r1" {
+		t.Errorf("expected unchanged revision after 304, got %q (%d requests)", pp.Revision(), srv.requests)
+	}
+
+	// changed bundle is swapped in place
+	srv.set(buildTestBundle(t, "r2", "welcome-v2"), `"r2"`)
+	now = now.Add(time.Minute)
+	reloader.MaybeRefresh(ctx)
+	if pp.Revision() != "r2" {
+		t.Errorf("expected revision r2, got %q", pp.Revision())
+	}
+
+	out, err := Evaluate[testBundleOutput](ctx, pp, map[string]any{"trigger": "signup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Template != "welcome-v2" {
+		t.Errorf("expected template from new bundle data, got %q", out.Template)
+	}
+
+	// a broken bundle keeps the current policy
+	srv.set([]byte("not a bundle"), `"r3"`)
+	now = now.Add(time.Minute)
+	reloader.MaybeRefresh(ctx)
+	if pp.Revision() != "r2" {
+		t.Errorf("expected revision r2 after failed reload, got %q", pp.Revision())
+	}
+}
@@ -0,0 +1,97 @@
+package opa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrNotModified is returned by a BundleSource when the bundle still matches
+// the ETag passed to Fetch.
+var ErrNotModified = errors.New("bundle not modified")
+
+// BundleSource fetches a gzipped tarball OPA bundle.
+type BundleSource interface {
+	// Fetch returns the bundle body and its ETag. If etag is set and the
+	// bundle has not changed, ErrNotModified is returned.
+	Fetch(ctx context.Context, etag string) (io.ReadCloser, string, error)
+}
+
+// HTTPBundleSource fetches bundles from an HTTP bundle server.
+type HTTPBundleSource struct {
+	Client *http.Client
+	URL    string
+	Token  string // optional bearer token
+}
+
+func (s *HTTPBundleSource) Fetch(ctx context.Context, etag string) (io.ReadCloser, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("error creating bundle request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: bundleFetchTimeout}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching bundle: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, resp.Header.Get("ETag"), nil
+	case http.StatusNotModified:
+		resp.Body.Close()
+		return nil, etag, ErrNotModified
+	default:
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("error fetching bundle: status=%d", resp.StatusCode)
+	}
+}
+
+// S3API is the subset of the S3 client used by S3BundleSource.
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3BundleSource fetches bundles from an S3 object.
+type S3BundleSource struct {
+	Client S3API
+	Bucket string
+	Key    string
+}
+
+func (s *S3BundleSource) Fetch(ctx context.Context, etag string) (io.ReadCloser, string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: awssdk.String(s.Bucket),
+		Key:    awssdk.String(s.Key),
+	}
+	if etag != "" {
+		input.IfNoneMatch = awssdk.String(etag)
+	}
+
+	out, err := s.Client.GetObject(ctx, input)
+	if err != nil {
+		var status interface{ HTTPStatusCode() int }
+		if errors.As(err, &status) && status.HTTPStatusCode() == http.StatusNotModified {
+			return nil, etag, ErrNotModified
+		}
+		return nil, "", fmt.Errorf("error fetching bundle from s3: %w", err)
+	}
+
+	return out.Body, awssdk.ToString(out.ETag), nil
+}
@@ -0,0 +1,199 @@
+// Package policytest evaluates fixture events against the sender policies and
+// compares the decisions with expected outputs.
+package policytest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/aws"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/opa"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/ratelimit"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/sender"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/verifier"
+)
+
+// Case is a Cognito event paired with the expected policy output. Expected is
+// matched as a subset: only the fields it sets are compared. EmailVerification,
+// RateLimit and Suppression stand in for the results the sender would add to
+// the policy input.
+type Case struct {
+	Name              string                            `json:"name"`
+	Event             json.RawMessage                   `json:"event"`
+	EmailVerification *verifier.EmailVerificationResult `json:"emailVerification,omitempty"`
+	RateLimit         *ratelimit.Result                 `json:"rateLimit,omitempty"`
+	Suppression       *suppression.Result               `json:"suppression,omitempty"`
+	Expected          json.RawMessage                   `json:"expected"`
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Name  string
+	Diffs []string
+	Err   error
+}
+
+// Passed reports whether the policy produced the expected output.
+func (r Result) Passed() bool {
+	return r.Err == nil && len(r.Diffs) == 0
+}
+
+// ReadCases reads a JSON array of cases from path.
+func ReadCases(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cases file: %w", err)
+	}
+
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("failed to parse cases file: %w", err)
+	}
+	return cases, nil
+}
+
+// Runner evaluates cases against the email and sms policies. SMSPolicy may be
+// nil if no sms cases are run.
+type Runner struct {
+	EmailPolicy *opa.PreparedPolicy
+	SMSPolicy   *opa.PreparedPolicy
+}
+
+// Run evaluates every case and returns the results in order.
+func (r *Runner) Run(ctx context.Context, cases []Case) []Result {
+	results := make([]Result, 0, len(cases))
+	for i, c := range cases {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("case %d", i)
+		}
+
+		res := Result{Name: name}
+		actual, err := r.evaluate(ctx, c)
+		if err != nil {
+			res.Err = err
+		} else {
+			var expected any
+			if err := json.Unmarshal(c.Expected, &expected); err != nil {
+				res.Err = fmt.Errorf("invalid expected output: %w", err)
+			} else {
+				res.Diffs = Diff(expected, actual)
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func (r *Runner) evaluate(ctx context.Context, c Case) (any, error) {
+	pp, input, err := r.policyInput(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := opa.Evaluate[any](ctx, pp, input)
+	if err != nil {
+		return nil, err
+	}
+	return *out, nil
+}
+
+// policyInput returns the policy for the trigger of c and the input the sender
+// would pass to it.
+func (r *Runner) policyInput(c Case) (*opa.PreparedPolicy, sender.PolicyInput, error) {
+	var header events.CognitoEventUserPoolsHeader
+	if err := json.Unmarshal(c.Event, &header); err != nil {
+		return nil, sender.PolicyInput{}, fmt.Errorf("failed to parse event header: %w", err)
+	}
+
+	var (
+		pp    *opa.PreparedPolicy
+		input sender.PolicyInput
+	)
+
+	if sender.IsSMSTrigger(header.TriggerSource) {
+		var event aws.CognitoEventUserPoolsCustomSMSSender
+		if err := json.Unmarshal(c.Event, &event); err != nil {
+			return nil, input, fmt.Errorf("failed to parse sms event: %w", err)
+		}
+		pp, input = r.SMSPolicy, sender.SMSPolicyInput(event)
+	} else {
+		var event aws.CognitoEventUserPoolsCustomEmailSender
+		if err := json.Unmarshal(c.Event, &event); err != nil {
+			return nil, input, fmt.Errorf("failed to parse email event: %w", err)
+		}
+		pp, input = r.EmailPolicy, sender.EmailPolicyInput(event, c.EmailVerification)
+	}
+
+	if pp == nil {
+		return nil, input, errors.New("no policy configured for trigger " + header.TriggerSource)
+	}
+
+	input.RateLimit = c.RateLimit
+	input.Suppression = c.Suppression
+	return pp, input, nil
+}
+
+// Diff returns the differences between expected and actual. Objects in
+// expected are matched as subsets of actual; all other values must be equal.
+func Diff(expected, actual any) []string {
+	return diff("", expected, actual)
+}
+
+func diff(path string, expected, actual any) []string {
+	exp, ok := expected.(map[string]any)
+	if !ok {
+		if reflect.DeepEqual(expected, actual) {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: expected %s, got %s", displayPath(path), format(expected), format(actual))}
+	}
+
+	act, ok := actual.(map[string]any)
+	if !ok {
+		return []string{fmt.Sprintf("%s: expected object, got %s", displayPath(path), format(actual))}
+	}
+
+	keys := make([]string, 0, len(exp))
+	for k := range exp {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var diffs []string
+	for _, k := range keys {
+		child := k
+		if path != "" {
+			child = path + "." + k
+		}
+		v, ok := act[k]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: expected %s, got <missing>", child, format(exp[k])))
+			continue
+		}
+		diffs = append(diffs, diff(child, exp[k], v)...)
+	}
+	return diffs
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+func format(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(bs)
+}
@@ -0,0 +1,147 @@
+package policytest
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/opa"
+)
+
+func TestDiff(t *testing.T) {
+	actual := map[string]any{
+		"action": "allow",
+		"allow": map[string]any{
+			"dstAddress": "user@example.org",
+			"srcAddress": "noreply@example.org",
+			"providers": map[string]any{
+				"ses": map[string]any{"templateId": "template-01"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		expected map[string]any
+		diffs    []string
+	}{
+		{
+			name:     "subset matches",
+			expected: map[string]any{"action": "allow", "allow": map[string]any{"dstAddress": "user@example.org"}},
+		},
+		{
+			name: "nested mismatch",
+			expected: map[string]any{"allow": map[string]any{
+				"providers": map[string]any{"ses": map[string]any{"templateId": "template-02"}},
+			}},
+			diffs: []string{`allow.providers.ses.templateId: expected "template-02", got "template-01"`},
+		},
+		{
+			name:     "missing field",
+			expected: map[string]any{"reason": "denied", "action": "deny"},
+			diffs: []string{
+				`action: expected "deny", got "allow"`,
+				`reason: expected "denied", got <missing>`,
+			},
+		},
+		{
+			name:     "object expected",
+			expected: map[string]any{"action": map[string]any{"kind": "allow"}},
+			diffs:    []string{`action: expected object, got "allow"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diffs := Diff(tc.expected, actual)
+			if strings.Join(diffs, "\n") != strings.Join(tc.diffs, "\n") {
+				t.Errorf("expected diffs %q, got %q", tc.diffs, diffs)
+			}
+		})
+	}
+}
+
+func TestRunner_MissingSMSPolicy(t *testing.T) {
+	r := &Runner{}
+	results := r.Run(context.Background(), []Case{{
+		Event:    []byte(`{"triggerSource":"CustomSMSSender_SignUp"}`),
+		Expected: []byte(`{"action":"allow"}`),
+	}})
+
+	if len(results) != 1 || results[0].Passed() {
+		t.Fatalf("expected failed result, got %+v", results)
+	}
+	if results[0].Name != "case 0" {
+		t.Errorf("expected default case name, got %q", results[0].Name)
+	}
+	if results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "no policy configured") {
+		t.Errorf("expected missing policy error, got %v", results[0].Err)
+	}
+}
+
+func TestRunner_FixtureCases(t *testing.T) {
+	ctx := context.Background()
+	fixtures := filepath.Join("..", "..", "fixtures")
+
+	emailPolicy := preparePolicy(t, filepath.Join(fixtures, "debug-policy.rego"), "data.cognito_custom_sender_email_policy.result")
+	smsPolicy := preparePolicy(t, filepath.Join(fixtures, "debug-sms-policy.rego"), "data.cognito_custom_sender_sms_policy.result")
+
+	cases, err := ReadCases(filepath.Join(fixtures, "policy-tests.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Runner{EmailPolicy: emailPolicy, SMSPolicy: smsPolicy}
+	for _, res := range r.Run(ctx, cases) {
+		if !res.Passed() {
+			t.Errorf("case %q failed: err=%v diffs=%v", res.Name, res.Err, res.Diffs)
+		}
+	}
+
+	// a wrong expectation is reported as a diff
+	cases[0].Expected = []byte(`{"allow":{"providers":{"ses":{"templateId":"template-99"}}}}`)
+	res := r.Run(ctx, cases[:1])[0]
+	if res.Passed() || len(res.Diffs) != 1 {
+		t.Errorf("expected one diff, got err=%v diffs=%v", res.Err, res.Diffs)
+	}
+}
+
+func preparePolicy(t *testing.T, path, query string) *opa.PreparedPolicy {
+	t.Helper()
+	policy, err := opa.ReadPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp, err := opa.PreparePolicy(context.Background(), policy, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pp
+}
+
+func TestRunner_PolicyInputIncludesRateLimitAndSuppression(t *testing.T) {
+	var c Case
+	err := json.Unmarshal([]byte(`{
+		"event": {"triggerSource": "CustomEmailSender_ForgotPassword", "request": {"userAttributes": {"email": "user@example.org"}}},
+		"rateLimit": {"exceeded": true, "limits": [{"key": "destination", "limit": 5, "window": "1h0m0s", "count": 6, "exceeded": true}]},
+		"suppression": {"suppressed": true, "entries": [{"source": "ses", "reason": "bounce"}]},
+		"expected": {"action": "deny"}
+	}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Runner{EmailPolicy: &opa.PreparedPolicy{}}
+	_, input, err := r.policyInput(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if input.RateLimit == nil || !input.RateLimit.Exceeded || input.RateLimit.Limits[0].Count != 6 {
+		t.Errorf("expected rate limit in policy input, got %+v", input.RateLimit)
+	}
+	if input.Suppression == nil || !input.Suppression.Suppressed || input.Suppression.Entries[0].Reason != "bounce" {
+		t.Errorf("expected suppression in policy input, got %+v", input.Suppression)
+	}
+}
@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed or slow sends that
+	// opens the breaker.
+	FailureThreshold int
+	// SlowCallThreshold counts successful sends slower than this as failures.
+	// Zero disables slow call detection.
+	SlowCallThreshold time.Duration
+	// Cooldown is how long the breaker stays open before a single half-open
+	// probe is let through.
+	Cooldown time.Duration
+}
+
+// CircuitBreaker tracks recent send outcomes of one provider. It lives for the
+// lifetime of the warm Lambda instance so an open breaker skips the provider
+// across invocations until the cooldown has passed.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+	return &CircuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// Allow reports whether a send may be attempted. Once the cooldown of an open
+// breaker has passed it moves to half-open and allows exactly one probe.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record records the outcome of a send that was allowed by Allow.
+func (b *CircuitBreaker) Record(err error, latency time.Duration) {
+	failed := err != nil || (b.cfg.SlowCallThreshold > 0 && latency > b.cfg.SlowCallThreshold)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// Release ends a send that was allowed by Allow without recording an outcome,
+// such as one rejected for a fault of the message. A half-open breaker stays
+// half-open and lets the next probe through.
+func (b *CircuitBreaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the current breaker state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,163 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// newTestBreaker returns a breaker driven by a manually advanced clock
+func newTestBreaker(cfg BreakerConfig) (*CircuitBreaker, *time.Time) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker(cfg)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute})
+	sendErr := errors.New("timeout")
+
+	b.Record(sendErr, time.Millisecond)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected closed after one failure, got %s", b.State())
+	}
+
+	b.Record(nil, time.Millisecond)
+	b.Record(sendErr, time.Millisecond)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected success to reset failure count, got %s", b.State())
+	}
+
+	b.Record(sendErr, time.Millisecond)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected open after consecutive failures, got %s", b.State())
+	}
+	if b.Allow() {
+		t.Error("expected open breaker to reject sends")
+	}
+}
+
+func TestCircuitBreaker_SlowCallsCountAsFailures(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 1, SlowCallThreshold: time.Second, Cooldown: time.Minute})
+
+	b.Record(nil, 500*time.Millisecond)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected fast call to keep breaker closed, got %s", b.State())
+	}
+
+	b.Record(nil, 2*time.Second)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected slow call to open breaker, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+	b.Record(errors.New("fail"), 0)
+
+	*now = now.Add(30 * time.Second)
+	if b.Allow() {
+		t.Fatal("expected breaker to stay open during cooldown")
+	}
+
+	*now = now.Add(31 * time.Second)
+	if !b.Allow() {
+		t.Fatal("expected one probe after cooldown")
+	}
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected half-open, got %s", b.State())
+	}
+	if b.Allow() {
+		t.Error("expected only one concurrent probe in half-open")
+	}
+
+	// failed probe reopens the breaker
+	b.Record(errors.New("fail"), 0)
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected failed probe to reopen breaker, got %s", b.State())
+	}
+
+	// successful probe closes it
+	*now = now.Add(2 * time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected probe after second cooldown")
+	}
+	b.Record(nil, 0)
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected successful probe to close breaker, got %s", b.State())
+	}
+}
+
+func TestFailoverProvider_SkipsOpenCircuit(t *testing.T) {
+	primary := &mockProvider{name: "ses", healthy: true, sendErr: errors.New("timeout")}
+	secondary := &mockProvider{name: "sendgrid", healthy: true}
+
+	fp := NewFailoverProvider([]Provider{primary, secondary},
+		WithCircuitBreaker(BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour}),
+	)
+
+	emailData := &types.EmailData{
+		DestinationAddress: "test@example.com",
+		Providers: &types.EmailProviderMap{
+			SES:      &types.EmailProviderData{TemplateID: "template-ses"},
+			SendGrid: &types.EmailProviderData{TemplateID: "template-sg"},
+		},
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, err := fp.Send(context.Background(), emailData); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+
+	if primary.GetSendCount() != 2 {
+		t.Errorf("expected primary to be skipped once its circuit opened, got %d sends", primary.GetSendCount())
+	}
+	if secondary.GetSendCount() != 4 {
+		t.Errorf("expected secondary to handle every send, got %d", secondary.GetSendCount())
+	}
+	if fp.Breaker("ses").State() != BreakerOpen {
+		t.Errorf("expected ses breaker to be open, got %s", fp.Breaker("ses").State())
+	}
+}
+
+func TestFailoverProvider_PermanentErrorReleasesHalfOpenProbe(t *testing.T) {
+	primary := &mockProvider{name: "ses", healthy: true, sendErr: errors.New("timeout")}
+	secondary := &mockProvider{name: "sendgrid", healthy: true}
+
+	fp := NewFailoverProvider([]Provider{primary, secondary},
+		WithCircuitBreaker(BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}),
+	)
+	now := time.Unix(0, 0)
+	breaker := fp.Breaker("ses")
+	breaker.now = func() time.Time { return now }
+
+	emailData := &types.EmailData{
+		DestinationAddress: "test@example.com",
+		Providers: &types.EmailProviderMap{
+			SES:      &types.EmailProviderData{TemplateID: "template-ses"},
+			SendGrid: &types.EmailProviderData{TemplateID: "template-sg"},
+		},
+	}
+
+	_, _ = fp.Send(context.Background(), emailData)
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open, got %s", breaker.State())
+	}
+
+	// the half-open probe is rejected for a fault of the message
+	now = now.Add(2 * time.Minute)
+	primary.sendErr = &ProviderError{Provider: "ses", Class: ErrorClassPermanent, Err: errors.New("invalid recipient")}
+	_, _ = fp.Send(context.Background(), emailData)
+
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("expected permanent error to leave breaker half-open, got %s", breaker.State())
+	}
+	if !breaker.Allow() {
+		t.Error("expected probe to be released for the next send")
+	}
+}
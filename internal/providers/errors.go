@@ -0,0 +1,212 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/textproto"
+)
+
+// ErrorClass describes who could fix a failed send.
+type ErrorClass int
+
+const (
+	// ErrorClassRetryable is a transient failure (throttling, 5xx, timeouts)
+	// that the same provider may accept on a later attempt.
+	ErrorClassRetryable ErrorClass = iota
+	// ErrorClassProvider is a failure specific to one provider (suspended
+	// account, unverified identity, missing template) that another provider
+	// could plausibly avoid.
+	ErrorClassProvider
+	// ErrorClassPermanent is a failure of the message itself (e.g. an invalid
+	// recipient) that no provider can fix.
+	ErrorClassPermanent
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassRetryable:
+		return "retryable"
+	case ErrorClassProvider:
+		return "provider"
+	case ErrorClassPermanent:
+		return "permanent"
+	default:
+		return "unknown"
+	}
+}
+
+// ProviderError is a classified send error returned by providers.
+type ProviderError struct {
+	Provider   string
+	Class      ErrorClass
+	StatusCode int
+	Err        error
+}
+
+func (e *ProviderError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClass returns the name of the error's class. It implements
+// audit.ClassifiedError so audit records never store the provider's error
+// text.
+func (e *ProviderError) ErrorClass() string {
+	return e.Class.String()
+}
+
+// ClassOf returns the class of err. Unclassified errors are treated as
+// provider errors so the failover chain still moves on, but are not retried.
+func ClassOf(err error) ErrorClass {
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Class
+	}
+	return ErrorClassProvider
+}
+
+// IsRetryable reports whether err may succeed when retried on the same provider.
+func IsRetryable(err error) bool {
+	return err != nil && ClassOf(err) == ErrorClassRetryable
+}
+
+// IsPermanent reports whether err cannot be fixed by any provider.
+func IsPermanent(err error) bool {
+	return err != nil && ClassOf(err) == ErrorClassPermanent
+}
+
+// ClassifyHTTPStatus classifies an HTTP API response status.
+func ClassifyHTTPStatus(status int) ErrorClass {
+	switch {
+	case status == http.StatusTooManyRequests, status == http.StatusRequestTimeout, status >= 500:
+		return ErrorClassRetryable
+	default:
+		return ErrorClassProvider
+	}
+}
+
+// sesErrorClasses maps SESv2 error codes to their class; unknown codes fall
+// back to the HTTP status.
+var sesErrorClasses = map[string]ErrorClass{
+	"TooManyRequestsException":           ErrorClassRetryable,
+	"ThrottlingException":                ErrorClassRetryable,
+	"Throttling":                         ErrorClassRetryable,
+	"InternalFailure":                    ErrorClassRetryable,
+	"ServiceUnavailable":                 ErrorClassRetryable,
+	"LimitExceededException":             ErrorClassProvider,
+	"AccountSuspendedException":          ErrorClassProvider,
+	"SendingPausedException":             ErrorClassProvider,
+	"MailFromDomainNotVerifiedException": ErrorClassProvider,
+	"MessageRejected":                    ErrorClassProvider,
+	"NotFoundException":                  ErrorClassProvider,
+	"BadRequestException":                ErrorClassPermanent,
+}
+
+// classifySESError wraps an SES API error in a ProviderError.
+func classifySESError(err error) error {
+	pe := &ProviderError{Provider: "ses", Class: classifyTransportError(err), Err: err}
+
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		pe.StatusCode = status.HTTPStatusCode()
+		pe.Class = ClassifyHTTPStatus(pe.StatusCode)
+	}
+
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		if class, ok := sesErrorClasses[apiErr.ErrorCode()]; ok {
+			pe.Class = class
+		}
+	}
+
+	return pe
+}
+
+// snsErrorClasses maps SNS error codes to their class; unknown codes fall
+// back to the HTTP status.
+var snsErrorClasses = map[string]ErrorClass{
+	"Throttled":          ErrorClassRetryable,
+	"KMSThrottling":      ErrorClassRetryable,
+	"InternalError":      ErrorClassRetryable,
+	"AuthorizationError": ErrorClassProvider,
+}
+
+// classifySNSError wraps an SNS API error in a ProviderError.
+func classifySNSError(err error) error {
+	pe := &ProviderError{Provider: "sns", Class: classifyTransportError(err), Err: err}
+
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		pe.StatusCode = status.HTTPStatusCode()
+		pe.Class = ClassifyHTTPStatus(pe.StatusCode)
+	}
+
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		if class, ok := snsErrorClasses[apiErr.ErrorCode()]; ok {
+			pe.Class = class
+		}
+	}
+
+	return pe
+}
+
+// twilioErrorClasses maps Twilio error codes to their class; unknown codes
+// fall back to the HTTP status.
+var twilioErrorClasses = map[int]ErrorClass{
+	21211: ErrorClassPermanent, // invalid 'To' phone number
+	21614: ErrorClassPermanent, // 'To' number is not a mobile number
+}
+
+// classifyTwilioError wraps a failed Twilio API response in a ProviderError.
+func classifyTwilioError(status, code int, err error) error {
+	pe := &ProviderError{
+		Provider:   "twilio",
+		Class:      ClassifyHTTPStatus(status),
+		StatusCode: status,
+		Err:        err,
+	}
+	if class, ok := twilioErrorClasses[code]; ok {
+		pe.Class = class
+	}
+	return pe
+}
+
+// classifySMTPError wraps an SMTP error in a ProviderError. 4xx replies are
+// transient, 550/551/553 in reply to RCPT reject the recipient and any other
+// 5xx reply is specific to the relay.
+func classifySMTPError(err error, rcpt bool) error {
+	pe := &ProviderError{Provider: "smtp", Class: classifyTransportError(err), Err: err}
+
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		pe.StatusCode = tpErr.Code
+		switch {
+		case tpErr.Code >= 400 && tpErr.Code < 500:
+			pe.Class = ErrorClassRetryable
+		case rcpt && (tpErr.Code == 550 || tpErr.Code == 551 || tpErr.Code == 553):
+			pe.Class = ErrorClassPermanent
+		default:
+			pe.Class = ErrorClassProvider
+		}
+	}
+
+	return pe
+}
+
+// classifyTransportError treats timeouts and network errors as retryable.
+func classifyTransportError(err error) ErrorClass {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassRetryable
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorClassRetryable
+	}
+	return ErrorClassProvider
+}
@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"testing"
+)
+
+// mockAPIError mimics the smithy API and response errors returned by the AWS SDK
+type mockAPIError struct {
+	code   string
+	status int
+}
+
+func (e *mockAPIError) Error() string       { return "api error " + e.code }
+func (e *mockAPIError) ErrorCode() string   { return e.code }
+func (e *mockAPIError) HTTPStatusCode() int { return e.status }
+
+func TestClassifySESError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorClass
+	}{
+		{"throttled", &mockAPIError{code: "TooManyRequestsException", status: 429}, ErrorClassRetryable},
+		{"account suspended", &mockAPIError{code: "AccountSuspendedException", status: 400}, ErrorClassProvider},
+		{"template not found", &mockAPIError{code: "NotFoundException", status: 404}, ErrorClassProvider},
+		{"bad request", &mockAPIError{code: "BadRequestException", status: 400}, ErrorClassPermanent},
+		{"unknown 5xx", &mockAPIError{code: "Unknown", status: 503}, ErrorClassRetryable},
+		{"unknown 4xx", &mockAPIError{code: "Unknown", status: 403}, ErrorClassProvider},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classifySESError(fmt.Errorf("error sending email: %w", tc.err))
+			if got := ClassOf(err); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+			if !errors.Is(err, tc.err) {
+				t.Error("expected original error to be wrapped")
+			}
+		})
+	}
+}
+
+func TestClassifySMTPError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		rcpt     bool
+		expected ErrorClass
+	}{
+		{"greylisted", &textproto.Error{Code: 451, Msg: "try later"}, true, ErrorClassRetryable},
+		{"unknown mailbox", &textproto.Error{Code: 550, Msg: "no such user"}, true, ErrorClassPermanent},
+		{"sender rejected", &textproto.Error{Code: 550, Msg: "sender rejected"}, false, ErrorClassProvider},
+		{"auth failed", &textproto.Error{Code: 535, Msg: "bad credentials"}, false, ErrorClassProvider},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ClassOf(classifySMTPError(tc.err, tc.rcpt)); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestClassifyHTTPStatus(t *testing.T) {
+	tests := map[int]ErrorClass{
+		429: ErrorClassRetryable,
+		500: ErrorClassRetryable,
+		502: ErrorClassRetryable,
+		400: ErrorClassProvider,
+		401: ErrorClassProvider,
+		403: ErrorClassProvider,
+	}
+
+	for status, expected := range tests {
+		if got := ClassifyHTTPStatus(status); got != expected {
+			t.Errorf("status %d: expected %s, got %s", status, expected, got)
+		}
+	}
+}
+
+func TestClassOf_Unclassified(t *testing.T) {
+	if got := ClassOf(errors.New("boom")); got != ErrorClassProvider {
+		t.Errorf("expected unclassified errors to be provider errors, got %s", got)
+	}
+	if IsRetryable(errors.New("boom")) {
+		t.Error("expected unclassified errors not to be retried")
+	}
+}
@@ -2,25 +2,91 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"time"
 
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/metrics"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// Actions taken by a FailoverProvider when no provider delivered the email.
+const (
+	AllFailedSwallow    = "swallow"
+	AllFailedError      = "error"
+	AllFailedDeadLetter = "deadletter"
+)
+
+// ErrAllProvidersFailed is returned by FailoverProvider.Send when no provider
+// delivered the email and the all-failed action is AllFailedError.
+var ErrAllProvidersFailed = errors.New("all providers failed")
+
 // FailoverProvider wraps multiple providers and attempts to send emails
 // through them in order, failing over to the next provider if one is unhealthy
 // or fails to send.
 type FailoverProvider struct {
-	providers []Provider
+	providers       []Provider
+	standby         []Provider
+	breakerConfig   *BreakerConfig
+	breakers        map[string]*CircuitBreaker
+	allFailedAction string
+	deadLetter      deadletter.Sink
+}
+
+// FailoverOption configures a FailoverProvider.
+type FailoverOption func(*FailoverProvider)
+
+// WithCircuitBreaker gives each provider in the chain its own circuit breaker,
+// so a provider that keeps failing or timing out is skipped until its cooldown
+// has passed.
+func WithCircuitBreaker(cfg BreakerConfig) FailoverOption {
+	return func(f *FailoverProvider) {
+		f.breakerConfig = &cfg
+	}
+}
+
+// WithStandbyProviders adds providers that are not part of the default chain
+// but can be selected per message through EmailData.ProviderOrder.
+func WithStandbyProviders(providers ...Provider) FailoverOption {
+	return func(f *FailoverProvider) {
+		f.standby = append(f.standby, providers...)
+	}
+}
+
+// WithAllFailedAction sets what happens when every provider fails or is
+// skipped: AllFailedSwallow logs and returns nil, AllFailedError returns
+// ErrAllProvidersFailed, and AllFailedDeadLetter publishes the undelivered
+// email to sink.
+func WithAllFailedAction(action string, sink deadletter.Sink) FailoverOption {
+	return func(f *FailoverProvider) {
+		f.allFailedAction = action
+		f.deadLetter = sink
+	}
 }
 
 // NewFailoverProvider creates a new failover provider with the given providers.
 // Providers are tried in order - the first healthy provider that successfully
 // sends the email wins.
-func NewFailoverProvider(providers []Provider) *FailoverProvider {
-	return &FailoverProvider{
-		providers: providers,
+func NewFailoverProvider(providers []Provider, opts ...FailoverOption) *FailoverProvider {
+	f := &FailoverProvider{
+		providers:       providers,
+		allFailedAction: AllFailedSwallow,
+	}
+	for _, opt := range opts {
+		opt(f)
 	}
+	if f.breakerConfig != nil {
+		f.breakers = make(map[string]*CircuitBreaker, len(f.providers)+len(f.standby))
+		for _, p := range slices.Concat(f.providers, f.standby) {
+			f.breakers[p.Name()] = NewCircuitBreaker(*f.breakerConfig)
+		}
+	}
+	return f
 }
 
 // Name returns "failover" to identify this as a failover provider.
@@ -28,13 +94,15 @@ func (f *FailoverProvider) Name() string {
 	return "failover"
 }
 
-// Send attempts to send an email through each provider in order.
+// Send attempts to send an email through each provider in order, using the
+// message's ProviderOrder when set.
 // It first checks if each provider is healthy (if it implements HealthChecker),
 // skipping unhealthy providers. If a provider fails to send, it tries the next one.
-func (f *FailoverProvider) Send(ctx context.Context, d *types.EmailData) error {
+func (f *FailoverProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
 	var lastErr error
 
-	for _, p := range f.providers {
+	chain := f.chain(ctx, d)
+	for _, p := range chain {
 		providerName := p.Name()
 
 		// Check if provider has required template config
@@ -51,42 +119,141 @@ func (f *FailoverProvider) Send(ctx context.Context, d *types.EmailData) error {
 				slog.WarnContext(ctx, "provider unhealthy, skipping",
 					"provider", providerName,
 				)
+				metrics.Count(ctx, "FailoverHops", metrics.Dim("Provider", providerName))
 				continue
 			}
 		}
 
+		// Skip providers whose circuit breaker is open
+		breaker := f.breakers[providerName]
+		if breaker != nil && !breaker.Allow() {
+			slog.WarnContext(ctx, "provider circuit open, skipping",
+				"provider", providerName,
+			)
+			metrics.Count(ctx, "FailoverHops", metrics.Dim("Provider", providerName))
+			continue
+		}
+
 		// Attempt to send
-		err := p.Send(ctx, d)
+		start := time.Now()
+		attemptCtx, span := tracing.Start(ctx, "FailoverProvider.attempt", attribute.String("provider", providerName))
+		receipt, err := p.Send(attemptCtx, d)
+		if receipt != nil && receipt.MessageID != "" {
+			span.SetAttributes(attribute.String("message_id", receipt.MessageID))
+		}
+		tracing.End(span, err)
+		if breaker != nil {
+			// a permanent error is a fault of the message, not the provider,
+			// so it neither counts as a failure nor proves the provider healthy
+			if IsPermanent(err) {
+				breaker.Release()
+			} else {
+				breaker.Record(err, time.Since(start))
+			}
+		}
 		if err == nil {
-			slog.InfoContext(ctx, "email sent successfully",
+			return receipt, nil
+		}
+
+		// Stop on errors no other provider could fix
+		if IsPermanent(err) {
+			slog.WarnContext(ctx, "provider send failed permanently, not failing over",
 				"provider", providerName,
+				"error", err,
 			)
-			return nil
+			lastErr = err
+			break
 		}
 
 		// Log failure and try next provider
 		slog.WarnContext(ctx, "provider send failed, trying next",
 			"provider", providerName,
+			"error_class", ClassOf(err).String(),
 			"error", err,
 		)
+		metrics.Count(ctx, "FailoverHops", metrics.Dim("Provider", providerName))
 		lastErr = err
 	}
 
-	// All providers failed or were skipped - log warning but don't return error
-	// to avoid Lambda retries. The email is lost but this is preferable to
-	// cascading failures when all providers are down.
+	return nil, f.handleAllFailed(ctx, d, chain, lastErr)
+}
+
+// chain returns the providers to try for d: those named in d.ProviderOrder,
+// in that order, or the default chain.
+func (f *FailoverProvider) chain(ctx context.Context, d *types.EmailData) []Provider {
+	if len(d.ProviderOrder) == 0 {
+		return f.providers
+	}
+
+	chain := make([]Provider, 0, len(d.ProviderOrder))
+	for _, name := range d.ProviderOrder {
+		p := f.provider(name)
+		if p == nil {
+			slog.WarnContext(ctx, "provider in policy order not configured, skipping",
+				"provider", name,
+			)
+			continue
+		}
+		chain = append(chain, p)
+	}
+	return chain
+}
+
+// provider returns the default or standby provider with the given name.
+func (f *FailoverProvider) provider(name string) Provider {
+	for _, p := range slices.Concat(f.providers, f.standby) {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// handleAllFailed applies the configured all-failed action. By default the
+// error is swallowed to avoid Lambda retries; the email is lost but this is
+// preferable to cascading failures when all providers are down.
+func (f *FailoverProvider) handleAllFailed(ctx context.Context, d *types.EmailData, chain []Provider, lastErr error) error {
+	metrics.Count(ctx, "AllProvidersFailed", metrics.Dim("Channel", "email"))
+
+	err := ErrAllProvidersFailed
 	if lastErr != nil {
+		err = fmt.Errorf("%w: %w", ErrAllProvidersFailed, lastErr)
 		slog.WarnContext(ctx, "all providers failed to send email",
 			"last_error", lastErr,
 			"destination", d.DestinationAddress,
+			"action", f.allFailedAction,
 		)
 	} else {
 		slog.WarnContext(ctx, "no providers available to send email",
 			"destination", d.DestinationAddress,
+			"action", f.allFailedAction,
 		)
 	}
 
-	return nil
+	switch f.allFailedAction {
+	case AllFailedError:
+		return err
+	case AllFailedDeadLetter:
+		msg := deadletter.NewMessage(ctx, d, providerNames(chain), err)
+		if dlErr := f.deadLetter.Publish(ctx, msg); dlErr != nil {
+			return fmt.Errorf("%w: dead-letter publish failed: %w", err, dlErr)
+		}
+		slog.InfoContext(ctx, "undelivered email published to dead-letter sink",
+			"sink", f.deadLetter.Name(),
+			"destination", d.DestinationAddress,
+		)
+		return nil
+	default:
+		return nil
+	}
+}
+
+func providerNames(providers []Provider) []string {
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name()
+	}
+	return names
 }
 
 // hasProviderConfig checks if the email data has configuration for the given provider.
@@ -97,9 +264,13 @@ func hasProviderConfig(d *types.EmailData, providerName string) bool {
 
 	switch providerName {
 	case "ses":
-		return d.Providers.SES != nil && d.Providers.SES.TemplateID != ""
+		return d.Providers.SES != nil && (d.Providers.SES.TemplateID != "" ||
+			d.Providers.SES.Rendering == types.RenderingLocal && d.Providers.SES.Subject != "")
 	case "sendgrid":
 		return d.Providers.SendGrid != nil && d.Providers.SendGrid.TemplateID != ""
+	case "smtp":
+		return d.Providers.SMTP != nil && (d.Providers.SMTP.TemplateID != "" ||
+			d.Providers.SMTP.Subject != "" && (d.Providers.SMTP.HTML != "" || d.Providers.SMTP.Text != ""))
 	default:
 		return false
 	}
@@ -109,3 +280,9 @@ func hasProviderConfig(d *types.EmailData, providerName string) bool {
 func (f *FailoverProvider) Providers() []Provider {
 	return f.providers
 }
+
+// Breaker returns the circuit breaker of the named provider, or nil if circuit
+// breaking is disabled.
+func (f *FailoverProvider) Breaker(name string) *CircuitBreaker {
+	return f.breakers[name]
+}
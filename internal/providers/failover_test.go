@@ -1,12 +1,21 @@
 package providers
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/audit"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/metrics"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // mockProvider is a test provider that can be configured to fail or succeed
@@ -22,11 +31,14 @@ func (m *mockProvider) Name() string {
 	return m.name
 }
 
-func (m *mockProvider) Send(ctx context.Context, d *types.EmailData) error {
+func (m *mockProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.sendCount++
-	return m.sendErr
+	if m.sendErr != nil {
+		return nil, m.sendErr
+	}
+	return newReceipt(m.name, m.name+"-message-id"), nil
 }
 
 func (m *mockProvider) IsHealthy(ctx context.Context) bool {
@@ -54,7 +66,7 @@ func TestFailoverProvider_SendsToFirstHealthyProvider(t *testing.T) {
 		},
 	}
 
-	err := fp.Send(context.Background(), emailData)
+	_, err := fp.Send(context.Background(), emailData)
 
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
@@ -82,7 +94,7 @@ func TestFailoverProvider_SkipsUnhealthyProvider(t *testing.T) {
 		},
 	}
 
-	err := fp.Send(context.Background(), emailData)
+	_, err := fp.Send(context.Background(), emailData)
 
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
@@ -110,7 +122,7 @@ func TestFailoverProvider_FailsOverOnSendError(t *testing.T) {
 		},
 	}
 
-	err := fp.Send(context.Background(), emailData)
+	_, err := fp.Send(context.Background(), emailData)
 
 	if err != nil {
 		t.Fatalf("expected no error (should failover), got: %v", err)
@@ -138,7 +150,7 @@ func TestFailoverProvider_WarnsAndReturnsNilWhenAllFail(t *testing.T) {
 		},
 	}
 
-	err := fp.Send(context.Background(), emailData)
+	_, err := fp.Send(context.Background(), emailData)
 
 	// Returns nil to avoid Lambda retries - email is lost but logged
 	if err != nil {
@@ -168,7 +180,7 @@ func TestFailoverProvider_SkipsProviderWithoutConfig(t *testing.T) {
 		},
 	}
 
-	err := fp.Send(context.Background(), emailData)
+	_, err := fp.Send(context.Background(), emailData)
 
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
@@ -196,7 +208,7 @@ func TestFailoverProvider_WarnsWhenNoProvidersAvailable(t *testing.T) {
 		},
 	}
 
-	err := fp.Send(context.Background(), emailData)
+	_, err := fp.Send(context.Background(), emailData)
 
 	// Returns nil to avoid Lambda retries - email is lost but logged
 	if err != nil {
@@ -261,6 +273,26 @@ func TestHasProviderConfig(t *testing.T) {
 			providerName: "sendgrid",
 			expected:     true,
 		},
+		{
+			name: "smtp with inline templates",
+			emailData: &types.EmailData{
+				Providers: &types.EmailProviderMap{
+					SMTP: &types.EmailProviderData{Subject: "subject", Text: "body"},
+				},
+			},
+			providerName: "smtp",
+			expected:     true,
+		},
+		{
+			name: "smtp without body template",
+			emailData: &types.EmailData{
+				Providers: &types.EmailProviderMap{
+					SMTP: &types.EmailProviderData{Subject: "subject"},
+				},
+			},
+			providerName: "smtp",
+			expected:     false,
+		},
 		{
 			name: "unknown provider",
 			emailData: &types.EmailData{
@@ -282,3 +314,228 @@ func TestHasProviderConfig(t *testing.T) {
 		})
 	}
 }
+
+// mockDeadLetterSink records published dead-letter messages
+type mockDeadLetterSink struct {
+	messages []*deadletter.Message
+	err      error
+}
+
+func (m *mockDeadLetterSink) Name() string {
+	return "mock"
+}
+
+func (m *mockDeadLetterSink) Publish(ctx context.Context, msg *deadletter.Message) error {
+	m.messages = append(m.messages, msg)
+	return m.err
+}
+
+func newAllFailingProviders() []Provider {
+	return []Provider{
+		&mockProvider{name: "ses", healthy: true, sendErr: errors.New("primary failed")},
+		&mockProvider{name: "sendgrid", healthy: true, sendErr: errors.New("secondary failed")},
+	}
+}
+
+func newFailoverEmailData() *types.EmailData {
+	return &types.EmailData{
+		DestinationAddress: "test@example.com",
+		Providers: &types.EmailProviderMap{
+			SES:      &types.EmailProviderData{TemplateID: "template-ses"},
+			SendGrid: &types.EmailProviderData{TemplateID: "template-sg"},
+		},
+	}
+}
+
+func TestFailoverProvider_AllFailedError(t *testing.T) {
+	fp := NewFailoverProvider(newAllFailingProviders(), WithAllFailedAction(AllFailedError, nil))
+
+	_, err := fp.Send(context.Background(), newFailoverEmailData())
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("expected ErrAllProvidersFailed, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "secondary failed") {
+		t.Errorf("expected last provider error to be wrapped, got: %v", err)
+	}
+}
+
+func TestFailoverProvider_AllFailedDeadLetter(t *testing.T) {
+	sink := &mockDeadLetterSink{}
+	fp := NewFailoverProvider(newAllFailingProviders(), WithAllFailedAction(AllFailedDeadLetter, sink))
+
+	if _, err := fp.Send(context.Background(), newFailoverEmailData()); err != nil {
+		t.Fatalf("expected no error after dead-letter publish, got: %v", err)
+	}
+	if len(sink.messages) != 1 {
+		t.Fatalf("expected 1 dead-letter message, got %d", len(sink.messages))
+	}
+	msg := sink.messages[0]
+	if len(msg.Providers) != 2 || msg.Providers[0] != "ses" {
+		t.Errorf("expected attempted providers to be recorded, got %v", msg.Providers)
+	}
+	if msg.Email.DestinationAddress != "test@example.com" {
+		t.Errorf("unexpected destination: %s", msg.Email.DestinationAddress)
+	}
+}
+
+func TestFailoverProvider_AllFailedDeadLetterPublishError(t *testing.T) {
+	sink := &mockDeadLetterSink{err: errors.New("queue unavailable")}
+	fp := NewFailoverProvider(newAllFailingProviders(), WithAllFailedAction(AllFailedDeadLetter, sink))
+
+	_, err := fp.Send(context.Background(), newFailoverEmailData())
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("expected ErrAllProvidersFailed when publish fails, got: %v", err)
+	}
+}
+
+func TestFailoverProvider_HonorsProviderOrder(t *testing.T) {
+	ses := &mockProvider{name: "ses", healthy: true}
+	sendgrid := &mockProvider{name: "sendgrid", healthy: true, sendErr: errors.New("sendgrid down")}
+	smtp := &mockProvider{name: "smtp", healthy: true}
+
+	fp := NewFailoverProvider([]Provider{ses, sendgrid}, WithStandbyProviders(smtp))
+
+	d := newFailoverEmailData()
+	d.Providers.SMTP = &types.EmailProviderData{TemplateID: "template-smtp"}
+	d.ProviderOrder = []string{"sendgrid", "unknown", "smtp"}
+
+	if _, err := fp.Send(context.Background(), d); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if sendgrid.GetSendCount() != 1 {
+		t.Errorf("expected sendgrid to be tried first, got %d calls", sendgrid.GetSendCount())
+	}
+	if smtp.GetSendCount() != 1 {
+		t.Errorf("expected standby smtp to be used, got %d calls", smtp.GetSendCount())
+	}
+	if ses.GetSendCount() != 0 {
+		t.Errorf("expected ses outside of the policy order to be skipped, got %d calls", ses.GetSendCount())
+	}
+}
+
+func TestFailoverProvider_StandbyNotInDefaultChain(t *testing.T) {
+	ses := &mockProvider{name: "ses", healthy: true, sendErr: errors.New("ses down")}
+	smtp := &mockProvider{name: "smtp", healthy: true}
+
+	fp := NewFailoverProvider([]Provider{ses}, WithStandbyProviders(smtp), WithCircuitBreaker(BreakerConfig{FailureThreshold: 1}))
+
+	d := newFailoverEmailData()
+	d.Providers.SMTP = &types.EmailProviderData{TemplateID: "template-smtp"}
+
+	_, _ = fp.Send(context.Background(), d)
+	if smtp.GetSendCount() != 0 {
+		t.Errorf("expected standby provider to be unused without a policy order, got %d calls", smtp.GetSendCount())
+	}
+	if fp.Breaker("smtp") == nil {
+		t.Error("expected standby provider to have a circuit breaker")
+	}
+}
+
+func TestFailoverProvider_RecordsMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	emitter := &metrics.Emitter{Writer: &buf, Namespace: "Test"}
+	scope := emitter.NewScope("pool", "client", "CustomEmailSender_SignUp")
+	ctx := metrics.NewContext(context.Background(), scope)
+
+	primary := NewMeteredProvider(&mockProvider{name: "ses", healthy: true, sendErr: errors.New("throttled")})
+	secondary := NewMeteredProvider(&mockProvider{name: "sendgrid", healthy: true})
+	fp := NewFailoverProvider([]Provider{primary, secondary})
+
+	emailData := &types.EmailData{
+		DestinationAddress: "test@example.com",
+		SourceAddress:      "from@example.com",
+		Providers: &types.EmailProviderMap{
+			SES:      &types.EmailProviderData{TemplateID: "template-ses"},
+			SendGrid: &types.EmailProviderData{TemplateID: "template-sg"},
+		},
+	}
+	if _, err := fp.Send(ctx, emailData); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	scope.Flush()
+
+	out := buf.String()
+	for _, want := range []string{
+		`"FailoverHops":1`,
+		`"ProviderErrors":1`,
+		`"Sends":1`,
+		`"Trigger":"CustomEmailSender_SignUp"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected metrics to contain %s, got %s", want, out)
+		}
+	}
+	if strings.Contains(out, "AllProvidersFailed") {
+		t.Errorf("expected no all-failed metric, got %s", out)
+	}
+}
+
+func TestFailoverProvider_RecordsAuditAttempts(t *testing.T) {
+	rec := audit.NewRecord("email", "pool", "sub", "CustomEmailSender_SignUp", "client")
+	ctx := audit.NewContext(context.Background(), rec)
+
+	primary := NewMeteredProvider(&mockProvider{name: "ses", healthy: true, sendErr: errors.New("throttled")})
+	secondary := NewMeteredProvider(&mockProvider{name: "sendgrid", healthy: true})
+	fp := NewFailoverProvider([]Provider{primary, secondary})
+
+	emailData := &types.EmailData{
+		DestinationAddress: "test@example.com",
+		SourceAddress:      "from@example.com",
+		Providers: &types.EmailProviderMap{
+			SES:      &types.EmailProviderData{TemplateID: "template-ses"},
+			SendGrid: &types.EmailProviderData{TemplateID: "template-sg"},
+		},
+	}
+	receipt, err := fp.Send(ctx, emailData)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if receipt.Provider != "sendgrid" || receipt.MessageID != "sendgrid-message-id" {
+		t.Errorf("expected receipt of the accepting provider, got %+v", receipt)
+	}
+	rec.Finish(nil)
+
+	if len(rec.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %+v", rec.Attempts)
+	}
+	if a := rec.Attempts[0]; a.Provider != "ses" || a.Outcome != audit.OutcomeFailed || a.ErrorClass != "provider" {
+		t.Errorf("unexpected first attempt: %+v", a)
+	}
+	if a := rec.Attempts[1]; a.Provider != "sendgrid" || a.Outcome != audit.OutcomeSent || a.MessageID != "sendgrid-message-id" {
+		t.Errorf("unexpected second attempt: %+v", a)
+	}
+	if rec.Outcome != audit.OutcomeSent {
+		t.Errorf("expected record outcome sent, got %q", rec.Outcome)
+	}
+}
+
+func TestFailoverProvider_TracesAttempts(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	primary := &mockProvider{name: "ses", healthy: true, sendErr: errors.New("throttled")}
+	secondary := &mockProvider{name: "sendgrid", healthy: true}
+	fp := NewFailoverProvider([]Provider{primary, secondary})
+
+	emailData := &types.EmailData{
+		DestinationAddress: "test@example.com",
+		SourceAddress:      "from@example.com",
+		Providers: &types.EmailProviderMap{
+			SES:      &types.EmailProviderData{TemplateID: "template-ses"},
+			SendGrid: &types.EmailProviderData{TemplateID: "template-sg"},
+		},
+	}
+	if _, err := fp.Send(context.Background(), emailData); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected one span per attempt, got %d", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error || spans[1].Status().Code == codes.Error {
+		t.Errorf("expected only the failed attempt to be an error, got %v and %v", spans[0].Status(), spans[1].Status())
+	}
+}
@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/audit"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/metrics"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// MeteredProvider records the latency and outcome of every send attempt of
+// the wrapped provider in the invocation's metrics and audit record.
+type MeteredProvider struct {
+	provider Provider
+}
+
+func NewMeteredProvider(p Provider) *MeteredProvider {
+	return &MeteredProvider{provider: p}
+}
+
+// Name returns the name of the wrapped provider.
+func (m *MeteredProvider) Name() string {
+	return m.provider.Name()
+}
+
+func (m *MeteredProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
+	start := time.Now()
+	receipt, err := m.provider.Send(ctx, d)
+
+	var messageID string
+	if receipt != nil {
+		messageID = receipt.MessageID
+	}
+	recordSend(ctx, m.Name(), messageID, start, err)
+	return receipt, err
+}
+
+// IsHealthy delegates to the wrapped provider if it implements HealthChecker.
+func (m *MeteredProvider) IsHealthy(ctx context.Context) bool {
+	if hc, ok := m.provider.(HealthChecker); ok {
+		return hc.IsHealthy(ctx)
+	}
+	return true
+}
+
+// Unwrap returns the wrapped provider.
+func (m *MeteredProvider) Unwrap() Provider {
+	return m.provider
+}
+
+// MeteredSMSProvider is the SMSProvider counterpart of MeteredProvider.
+type MeteredSMSProvider struct {
+	provider SMSProvider
+}
+
+func NewMeteredSMSProvider(p SMSProvider) *MeteredSMSProvider {
+	return &MeteredSMSProvider{provider: p}
+}
+
+// Name returns the name of the wrapped provider.
+func (m *MeteredSMSProvider) Name() string {
+	return m.provider.Name()
+}
+
+func (m *MeteredSMSProvider) SendSMS(ctx context.Context, d *types.SMSData) error {
+	start := time.Now()
+	err := m.provider.SendSMS(ctx, d)
+	recordSend(ctx, m.Name(), "", start, err)
+	return err
+}
+
+// IsHealthy delegates to the wrapped provider if it implements HealthChecker.
+func (m *MeteredSMSProvider) IsHealthy(ctx context.Context) bool {
+	if hc, ok := m.provider.(HealthChecker); ok {
+		return hc.IsHealthy(ctx)
+	}
+	return true
+}
+
+func recordSend(ctx context.Context, provider, messageID string, start time.Time, err error) {
+	latency := time.Since(start)
+	attempt := audit.Attempt{
+		Provider:   provider,
+		Outcome:    audit.OutcomeSent,
+		MessageID:  messageID,
+		StartedAt:  start.UTC(),
+		DurationMs: latency.Milliseconds(),
+	}
+
+	metrics.Duration(ctx, "ProviderLatency", latency, metrics.Dim("Provider", provider))
+	if err != nil {
+		attempt.Outcome = audit.OutcomeFailed
+		// the error text is left out as it can echo the recipient's address
+		attempt.ErrorClass = ClassOf(err).String()
+		var pe *ProviderError
+		if errors.As(err, &pe) {
+			attempt.StatusCode = pe.StatusCode
+		}
+		audit.FromContext(ctx).AddAttempt(attempt)
+
+		metrics.Count(ctx, "ProviderErrors",
+			metrics.Dim("Provider", provider),
+			metrics.Dim("ErrorClass", attempt.ErrorClass),
+		)
+		return
+	}
+	audit.FromContext(ctx).AddAttempt(attempt)
+
+	metrics.Count(ctx, "Sends",
+		metrics.Dim("Provider", provider),
+		metrics.Dim("Trigger", metrics.Trigger(ctx)),
+	)
+}
@@ -4,32 +4,143 @@ import (
 	"context"
 	"fmt"
 	"net/mail"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/templates"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
 )
 
 type Provider interface {
 	Name() string
-	Send(ctx context.Context, d *types.EmailData) error
+	Send(ctx context.Context, d *types.EmailData) (*Receipt, error)
+}
+
+// Receipt describes a message accepted by a provider. MessageID is the
+// provider's own ID, used to correlate with its event stream; it is empty for
+// dry runs.
+type Receipt struct {
+	Provider   string    `json:"provider"`
+	MessageID  string    `json:"messageId,omitempty"`
+	AcceptedAt time.Time `json:"acceptedAt"`
+	// Attempts is the number of sends made with Provider, including retries.
+	Attempts int `json:"attempts"`
+}
+
+func newReceipt(provider, messageID string) *Receipt {
+	return &Receipt{
+		Provider:   provider,
+		MessageID:  messageID,
+		AcceptedAt: time.Now().UTC(),
+		Attempts:   1,
+	}
 }
 
 // NewProvider creates a provider based on configuration.
 // If failover is enabled, it creates a FailoverProvider with the primary provider
-// and all failover providers in order.
+// and all failover providers in order. Every other configured provider is
+// created as a standby so policies can select it per message.
 func NewProvider(cfg *config.Config) (Provider, error) {
+	renderer, err := newRenderer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	// If failover is enabled, create a failover provider chain
 	if cfg.AppEmailFailoverEnabled && len(cfg.AppEmailFailoverProviders) > 0 {
-		return newFailoverProvider(cfg)
+		return newFailoverProvider(cfg, renderer)
 	}
 
 	// Single provider mode
-	return createProvider(cfg.AppEmailProvider, cfg)
+	p, err := createProvider(cfg.AppEmailProvider, cfg, renderer)
+	if err != nil {
+		return nil, err
+	}
+
+	standby, err := createStandbyProviders(cfg, renderer, []string{cfg.AppEmailProvider})
+	if err != nil {
+		return nil, err
+	}
+	if len(standby) == 0 {
+		return wrapProvider(p, cfg), nil
+	}
+	return NewRoutingProvider(wrapProvider(p, cfg), standby...), nil
+}
+
+// ConfiguredProviders returns the names of all providers that can send: the
+// configured provider, the failover providers, SendGrid with an API key and
+// SMTP with a host. SES needs no settings of its own, so it is only included
+// when named as the provider or a failover provider.
+func ConfiguredProviders(cfg *config.Config) []string {
+	names := []string{cfg.AppEmailProvider}
+	if cfg.AppEmailFailoverEnabled {
+		names = append(names, cfg.AppEmailFailoverProviders...)
+	}
+	if cfg.SendGridEmailSendApiKey != "" {
+		names = append(names, "sendgrid")
+	}
+	if cfg.SMTPHost != "" {
+		names = append(names, "smtp")
+	}
+
+	var unique []string
+	for _, name := range names {
+		if !slices.Contains(unique, name) {
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// createStandbyProviders creates every configured provider not in exclude.
+func createStandbyProviders(cfg *config.Config, renderer *templates.Renderer, exclude []string) ([]Provider, error) {
+	var standby []Provider
+	for _, name := range ConfiguredProviders(cfg) {
+		if slices.Contains(exclude, name) {
+			continue
+		}
+		p, err := createProvider(name, cfg, renderer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider %s: %w", name, err)
+		}
+		standby = append(standby, wrapProvider(p, cfg))
+	}
+	return standby, nil
+}
+
+// wrapProvider meters every send attempt of p and wraps it in a RetryProvider
+// when retries are configured.
+func wrapProvider(p Provider, cfg *config.Config) Provider {
+	p = NewMeteredProvider(p)
+	if cfg.AppEmailRetryMaxAttempts <= 1 {
+		return p
+	}
+	return NewRetryProvider(p, RetryPolicy{
+		MaxAttempts: cfg.AppEmailRetryMaxAttempts,
+		BaseDelay:   cfg.AppEmailRetryBaseDelay,
+		MaxDelay:    cfg.AppEmailRetryMaxDelay,
+	})
+}
+
+// newRenderer creates the local template renderer shared by all providers.
+// Without a templates path only inline templates can be rendered.
+func newRenderer(cfg *config.Config) (*templates.Renderer, error) {
+	if cfg.AppEmailTemplatesPath == "" {
+		return nil, nil
+	}
+	r, err := templates.NewDirRenderer(cfg.AppEmailTemplatesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email templates: %w", err)
+	}
+	return r, nil
 }
 
 // newFailoverProvider creates a FailoverProvider with the primary provider first,
 // followed by all configured failover providers.
-func newFailoverProvider(cfg *config.Config) (Provider, error) {
+func newFailoverProvider(cfg *config.Config, renderer *templates.Renderer) (Provider, error) {
 	// Build list of all providers: primary first, then failover providers
 	providerNames := append([]string{cfg.AppEmailProvider}, cfg.AppEmailFailoverProviders...)
 
@@ -46,23 +157,54 @@ func newFailoverProvider(cfg *config.Config) (Provider, error) {
 	// Create provider instances
 	var providers []Provider
 	for _, name := range uniqueNames {
-		p, err := createProvider(name, cfg)
+		p, err := createProvider(name, cfg, renderer)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create provider %s: %w", name, err)
 		}
-		providers = append(providers, p)
+		providers = append(providers, wrapProvider(p, cfg))
 	}
 
-	return NewFailoverProvider(providers), nil
+	standby, err := createStandbyProviders(cfg, renderer, uniqueNames)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []FailoverOption
+	if len(standby) > 0 {
+		opts = append(opts, WithStandbyProviders(standby...))
+	}
+	if cfg.AppEmailFailoverBreakerEnabled {
+		opts = append(opts, WithCircuitBreaker(BreakerConfig{
+			FailureThreshold:  cfg.AppEmailFailoverBreakerFailureThreshold,
+			SlowCallThreshold: cfg.AppEmailFailoverBreakerSlowCallThreshold,
+			Cooldown:          cfg.AppEmailFailoverBreakerCooldown,
+		}))
+	}
+
+	if cfg.AppEmailFailoverAllFailedAction != "" {
+		var sink deadletter.Sink
+		if cfg.AppEmailFailoverAllFailedAction == AllFailedDeadLetter {
+			s, err := deadletter.NewSink(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create dead-letter sink: %w", err)
+			}
+			sink = s
+		}
+		opts = append(opts, WithAllFailedAction(cfg.AppEmailFailoverAllFailedAction, sink))
+	}
+
+	return NewFailoverProvider(providers, opts...), nil
 }
 
 // createProvider creates a single provider by name.
-func createProvider(name string, cfg *config.Config) (Provider, error) {
+func createProvider(name string, cfg *config.Config, renderer *templates.Renderer) (Provider, error) {
 	switch name {
 	case "sendgrid":
 		return NewSendGridProvider(cfg), nil
 	case "ses":
-		return NewSESProvider(cfg), nil
+		return NewSESProvider(cfg, renderer), nil
+	case "smtp":
+		return NewSMTPProvider(cfg, renderer), nil
 	default:
 		return nil, fmt.Errorf("unknown email provider: %s", name)
 	}
@@ -78,6 +220,31 @@ func MergeTemplateData(base, additional map[string]any) map[string]any {
 	return base
 }
 
+// Recipients returns the To, Cc and Bcc recipients of d. Addresses are
+// deduplicated across all three lists (To wins over Cc, Cc over Bcc) because
+// providers reject or double-deliver repeated recipients.
+func Recipients(d *types.EmailData) (to, cc, bcc []string) {
+	seen := make(map[string]bool)
+	add := func(list []string, addrs ...string) []string {
+		for _, a := range addrs {
+			_, bare := ParseNameAddr(a)
+			key := strings.ToLower(bare)
+			if bare == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			list = append(list, a)
+		}
+		return list
+	}
+
+	to = add(to, d.DestinationAddress)
+	to = add(to, d.DestinationAddresses...)
+	cc = add(cc, d.CcAddresses...)
+	bcc = add(bcc, d.BccAddresses...)
+	return to, cc, bcc
+}
+
 func ParseNameAddr(s string) (string, string) {
 	addr, err := mail.ParseAddress(s)
 	if err != nil || addr == nil {
@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// RetryPolicy bounds the retries of retryable send errors.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles per retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts.
+	MaxDelay time.Duration
+}
+
+// backoff returns an equal-jitter delay for the given retry (1-based): half
+// of the exponential delay plus a random share of the other half.
+func (r RetryPolicy) backoff(retry int) time.Duration {
+	d := r.BaseDelay << (retry - 1)
+	if d <= 0 || (r.MaxDelay > 0 && d > r.MaxDelay) {
+		d = r.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// RetryProvider retries retryable send errors of the wrapped provider with
+// jittered exponential backoff. Retries stop early when the next backoff
+// would run past the context deadline, leaving time to fail over.
+type RetryProvider struct {
+	provider Provider
+	policy   RetryPolicy
+}
+
+// NewRetryProvider wraps p with the given retry policy.
+func NewRetryProvider(p Provider, policy RetryPolicy) *RetryProvider {
+	return &RetryProvider{provider: p, policy: policy}
+}
+
+// Name returns the name of the wrapped provider.
+func (r *RetryProvider) Name() string {
+	return r.provider.Name()
+}
+
+func (r *RetryProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
+	for attempt := 1; ; attempt++ {
+		receipt, err := r.provider.Send(ctx, d)
+		if err == nil {
+			if receipt != nil {
+				receipt.Attempts = attempt
+			}
+			return receipt, nil
+		}
+		if !IsRetryable(err) || attempt >= r.policy.MaxAttempts {
+			return nil, err
+		}
+
+		delay := r.policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			slog.WarnContext(ctx, "not retrying provider send, deadline too close",
+				"provider", r.Name(),
+				"attempt", attempt,
+			)
+			return nil, err
+		}
+
+		slog.WarnContext(ctx, "provider send failed, retrying",
+			"provider", r.Name(),
+			"attempt", attempt,
+			"delay", delay,
+			"error", err,
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// IsHealthy delegates to the wrapped provider if it implements HealthChecker.
+func (r *RetryProvider) IsHealthy(ctx context.Context) bool {
+	if hc, ok := r.provider.(HealthChecker); ok {
+		return hc.IsHealthy(ctx)
+	}
+	return true
+}
+
+// Unwrap returns the wrapped provider.
+func (r *RetryProvider) Unwrap() Provider {
+	return r.provider
+}
@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// flakyProvider fails with the given errors before succeeding
+type flakyProvider struct {
+	errs  []error
+	calls int
+}
+
+func (f *flakyProvider) Name() string {
+	return "ses"
+}
+
+func (f *flakyProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return nil, f.errs[f.calls-1]
+	}
+	return newReceipt(f.Name(), "message-id"), nil
+}
+
+var (
+	errRetryable = &ProviderError{Class: ErrorClassRetryable, Err: errors.New("throttled")}
+	errProvider  = &ProviderError{Class: ErrorClassProvider, Err: errors.New("suspended")}
+	errPermanent = &ProviderError{Class: ErrorClassPermanent, Err: errors.New("invalid recipient")}
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryProvider_RetriesRetryableErrors(t *testing.T) {
+	p := &flakyProvider{errs: []error{errRetryable, errRetryable}}
+	rp := NewRetryProvider(p, testRetryPolicy)
+
+	receipt, err := rp.Send(context.Background(), &types.EmailData{})
+	if err != nil {
+		t.Fatalf("expected success after retries, got: %v", err)
+	}
+	if p.calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", p.calls)
+	}
+	if receipt.Attempts != 3 {
+		t.Errorf("expected receipt to count 3 attempts, got %d", receipt.Attempts)
+	}
+}
+
+func TestRetryProvider_StopsAtMaxAttempts(t *testing.T) {
+	p := &flakyProvider{errs: []error{errRetryable, errRetryable, errRetryable, errRetryable}}
+	rp := NewRetryProvider(p, testRetryPolicy)
+
+	if _, err := rp.Send(context.Background(), &types.EmailData{}); !IsRetryable(err) {
+		t.Fatalf("expected last retryable error, got: %v", err)
+	}
+	if p.calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", p.calls)
+	}
+}
+
+func TestRetryProvider_DoesNotRetryOtherErrors(t *testing.T) {
+	for _, sendErr := range []error{errProvider, errPermanent, errors.New("unclassified")} {
+		p := &flakyProvider{errs: []error{sendErr}}
+		rp := NewRetryProvider(p, testRetryPolicy)
+
+		if _, err := rp.Send(context.Background(), &types.EmailData{}); err == nil {
+			t.Fatalf("expected error %v to be returned", sendErr)
+		}
+		if p.calls != 1 {
+			t.Errorf("expected 1 attempt for %v, got %d", sendErr, p.calls)
+		}
+	}
+}
+
+func TestRetryProvider_RespectsDeadline(t *testing.T) {
+	p := &flakyProvider{errs: []error{errRetryable, errRetryable}}
+	rp := NewRetryProvider(p, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := rp.Send(ctx, &types.EmailData{}); err == nil {
+		t.Fatal("expected error when deadline is too close to retry")
+	}
+	if p.calls != 1 {
+		t.Errorf("expected no retry past the deadline, got %d attempts", p.calls)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected retry to give up without sleeping past the deadline")
+	}
+}
+
+func TestFailoverProvider_StopsOnPermanentError(t *testing.T) {
+	primary := &mockProvider{name: "ses", healthy: true, sendErr: errPermanent}
+	secondary := &mockProvider{name: "sendgrid", healthy: true}
+
+	fp := NewFailoverProvider([]Provider{primary, secondary}, WithAllFailedAction(AllFailedError, nil))
+
+	_, err := fp.Send(context.Background(), newFailoverEmailData())
+	if !errors.Is(err, ErrAllProvidersFailed) || !IsPermanent(err) {
+		t.Fatalf("expected permanent all-failed error, got: %v", err)
+	}
+	if secondary.GetSendCount() != 0 {
+		t.Errorf("expected no failover on permanent error, got %d sends", secondary.GetSendCount())
+	}
+}
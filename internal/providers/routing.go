@@ -0,0 +1,73 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// ErrNoProviderInOrder is returned by RoutingProvider.Send when none of the
+// providers in the message's ProviderOrder is configured for it.
+var ErrNoProviderInOrder = errors.New("no configured provider in provider order")
+
+// RoutingProvider sends through the default provider unless the message names
+// providers in ProviderOrder, in which case the first listed provider that is
+// configured for the message is used. It is used when failover is disabled, so
+// there is no fallback after a failed send.
+type RoutingProvider struct {
+	def       Provider
+	providers map[string]Provider
+}
+
+// NewRoutingProvider creates a routing provider with def as the default and
+// standby providers selectable by name.
+func NewRoutingProvider(def Provider, standby ...Provider) *RoutingProvider {
+	r := &RoutingProvider{
+		def:       def,
+		providers: map[string]Provider{def.Name(): def},
+	}
+	for _, p := range standby {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+// Name returns the name of the default provider.
+func (r *RoutingProvider) Name() string {
+	return r.def.Name()
+}
+
+func (r *RoutingProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
+	if len(d.ProviderOrder) == 0 {
+		return r.def.Send(ctx, d)
+	}
+
+	for _, name := range d.ProviderOrder {
+		p, ok := r.providers[name]
+		if !ok {
+			slog.WarnContext(ctx, "provider in policy order not configured, skipping",
+				"provider", name,
+			)
+			continue
+		}
+		if !hasProviderConfig(d, name) {
+			slog.WarnContext(ctx, "provider missing template config, skipping",
+				"provider", name,
+			)
+			continue
+		}
+		return p.Send(ctx, d)
+	}
+
+	return nil, ErrNoProviderInOrder
+}
+
+// IsHealthy delegates to the default provider if it implements HealthChecker.
+func (r *RoutingProvider) IsHealthy(ctx context.Context) bool {
+	if hc, ok := r.def.(HealthChecker); ok {
+		return hc.IsHealthy(ctx)
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRoutingProvider(t *testing.T) {
+	testCases := []struct {
+		name     string
+		order    []string
+		expected string
+		err      error
+	}{
+		{name: "default without order", expected: "ses"},
+		{name: "first listed provider", order: []string{"sendgrid", "ses"}, expected: "sendgrid"},
+		{name: "skips unknown and unconfigured", order: []string{"mailgun", "smtp", "sendgrid"}, expected: "sendgrid"},
+		{name: "no usable provider", order: []string{"smtp"}, err: ErrNoProviderInOrder},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ses := &mockProvider{name: "ses", healthy: true}
+			sendgrid := &mockProvider{name: "sendgrid", healthy: true}
+			smtp := &mockProvider{name: "smtp", healthy: true}
+			r := NewRoutingProvider(ses, sendgrid, smtp)
+
+			// no smtp provider data, so smtp is not configured for the message
+			d := newFailoverEmailData()
+			d.ProviderOrder = tc.order
+
+			_, err := r.Send(context.Background(), d)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+
+			for _, p := range []*mockProvider{ses, sendgrid, smtp} {
+				want := 0
+				if p.name == tc.expected {
+					want = 1
+				}
+				if p.GetSendCount() != want {
+					t.Errorf("expected %s to be called %d times, got %d", p.name, want, p.GetSendCount())
+				}
+			}
+		})
+	}
+}
+
+func TestRoutingProvider_Name(t *testing.T) {
+	r := NewRoutingProvider(&mockProvider{name: "smtp"}, &mockProvider{name: "ses"})
+	if r.Name() != "smtp" {
+		t.Errorf("expected default provider name, got %q", r.Name())
+	}
+}
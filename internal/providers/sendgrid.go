@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
@@ -28,22 +30,35 @@ func (p *SendGridProvider) Name() string {
 	return "sendgrid"
 }
 
-func (p *SendGridProvider) Send(ctx context.Context, d *types.EmailData) error {
+func (p *SendGridProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
 	d.Providers.SendGrid.TemplateData = MergeTemplateData(d.Providers.SendGrid.TemplateData, map[string]any{"code": d.VerificationCode})
 
 	srcName, srcAddr := ParseNameAddr(d.SourceAddress)
-	_, dstAddr := ParseNameAddr(d.DestinationAddress)
 
 	if p.DryRun {
-		return p.SendDryRun(ctx, d)
+		if err := p.SendDryRun(ctx, d); err != nil {
+			return nil, err
+		}
+		return newReceipt(p.Name(), ""), nil
 	}
 
 	msg := mail.NewV3Mail()
 	msg.SetFrom(mail.NewEmail(srcName, srcAddr))
 	msg.SetTemplateID(d.Providers.SendGrid.TemplateID)
+	if len(d.ReplyToAddresses) > 0 {
+		var replyTo []*mail.Email
+		for _, addr := range d.ReplyToAddresses {
+			name, addr := ParseNameAddr(addr)
+			replyTo = append(replyTo, mail.NewEmail(name, addr))
+		}
+		msg.SetReplyToList(replyTo)
+	}
 
+	to, cc, bcc := Recipients(d)
 	data := mail.NewPersonalization()
-	data.AddTos(mail.NewEmail("", dstAddr))
+	data.AddTos(sendGridEmails(to)...)
+	data.AddCCs(sendGridEmails(cc)...)
+	data.AddBCCs(sendGridEmails(bcc)...)
 	for k, v := range d.Providers.SendGrid.TemplateData {
 		data.SetDynamicTemplateData(k, v)
 	}
@@ -51,21 +66,41 @@ func (p *SendGridProvider) Send(ctx context.Context, d *types.EmailData) error {
 
 	resp, err := p.Client.Send(msg)
 	if err != nil {
-		return fmt.Errorf("sendgrid api error: %w", err)
+		return nil, &ProviderError{
+			Provider: "sendgrid",
+			Class:    ErrorClassRetryable,
+			Err:      fmt.Errorf("sendgrid api error: %w", err),
+		}
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("sendgrid send failed: status=%d body=%s", resp.StatusCode, resp.Body)
+		return nil, &ProviderError{
+			Provider:   "sendgrid",
+			Class:      ClassifyHTTPStatus(resp.StatusCode),
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("sendgrid send failed: status=%d body=%s", resp.StatusCode, resp.Body),
+		}
 	}
 
-	return nil
+	return newReceipt(p.Name(), http.Header(resp.Headers).Get("X-Message-Id")), nil
 }
 
 func (p *SendGridProvider) SendDryRun(ctx context.Context, d *types.EmailData) error {
 	slog.DebugContext(ctx, "dry-run sendgrid send",
 		"template_id", d.Providers.SendGrid.TemplateID,
-		"template_data", d.Providers.SendGrid.TemplateData,
+		"template_data", deadletter.WithoutCodeData(d.Providers.SendGrid.TemplateData),
 		"src_address", d.SourceAddress,
 		"dst_address", d.DestinationAddress,
+		"cc_count", len(d.CcAddresses),
+		"bcc_count", len(d.BccAddresses),
 	)
 	return nil
 }
+
+func sendGridEmails(addrs []string) []*mail.Email {
+	out := make([]*mail.Email, 0, len(addrs))
+	for _, a := range addrs {
+		name, addr := ParseNameAddr(a)
+		out = append(out, mail.NewEmail(name, addr))
+	}
+	return out
+}
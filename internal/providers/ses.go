@@ -5,31 +5,41 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sort"
 
 	awssdk "github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/ses"
-	awstypes "github.com/aws/aws-sdk-go-v2/service/ses/types"
 	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	awstypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/templates"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
 )
 
+// SESAPI is the subset of the SESv2 client used to send email.
+type SESAPI interface {
+	SendEmail(ctx context.Context, params *sesv2.SendEmailInput, optFns ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error)
+}
+
 type SESProvider struct {
-	Client        *ses.Client
+	Client        SESAPI
+	Renderer      *templates.Renderer
 	DryRun        bool
 	healthChecker *SESHealthChecker
 }
 
-func NewSESProvider(cfg *config.Config) *SESProvider {
+func NewSESProvider(cfg *config.Config, renderer *templates.Renderer) *SESProvider {
+	client := sesv2.NewFromConfig(*cfg.AWSConfig)
+
 	p := &SESProvider{
-		Client: ses.NewFromConfig(*cfg.AWSConfig),
-		DryRun: !cfg.AppSendEnabled,
+		Client:   client,
+		Renderer: renderer,
+		DryRun:   !cfg.AppSendEnabled,
 	}
 
 	// Only create health checker if failover is enabled
 	if cfg.AppEmailFailoverEnabled {
-		sesv2Client := sesv2.NewFromConfig(*cfg.AWSConfig)
-		p.healthChecker = NewSESHealthChecker(sesv2Client, cfg.AppEmailFailoverCacheTTL)
+		p.healthChecker = NewSESHealthChecker(client, cfg.AppEmailFailoverCacheTTL)
 	}
 
 	return p
@@ -39,33 +49,88 @@ func (p *SESProvider) Name() string {
 	return "ses"
 }
 
-func (p *SESProvider) Send(ctx context.Context, d *types.EmailData) error {
-	d.Providers.SES.TemplateData = MergeTemplateData(d.Providers.SES.TemplateData, map[string]any{"code": d.VerificationCode})
+func (p *SESProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
+	pd := d.Providers.SES
+	pd.TemplateData = MergeTemplateData(pd.TemplateData, map[string]any{"code": d.VerificationCode})
+
+	content, err := p.buildContent(d)
+	if err != nil {
+		return nil, err
+	}
 
 	if p.DryRun {
-		return p.SendDryRun(ctx, d)
+		if err := p.SendDryRun(ctx, d); err != nil {
+			return nil, err
+		}
+		return newReceipt(p.Name(), ""), nil
 	}
 
-	dataJSON, err := json.Marshal(d.Providers.SES.TemplateData)
+	to, cc, bcc := Recipients(d)
+	input := &sesv2.SendEmailInput{
+		FromEmailAddress: awssdk.String(d.SourceAddress),
+		Destination: &awstypes.Destination{
+			ToAddresses:  to,
+			CcAddresses:  cc,
+			BccAddresses: bcc,
+		},
+		Content:          content,
+		ReplyToAddresses: d.ReplyToAddresses,
+		EmailTags:        messageTags(pd.Tags),
+	}
+	if pd.ConfigurationSet != "" {
+		input.ConfigurationSetName = awssdk.String(pd.ConfigurationSet)
+	}
+	if pd.FeedbackForwardingAddress != "" {
+		input.FeedbackForwardingEmailAddress = awssdk.String(pd.FeedbackForwardingAddress)
+	}
+
+	out, err := p.Client.SendEmail(ctx, input)
 	if err != nil {
-		return fmt.Errorf("error marshaling template data: %w", err)
+		return nil, classifySESError(fmt.Errorf("error sending email: %w", err))
 	}
 
-	_, err = p.Client.SendTemplatedEmail(ctx, &ses.SendTemplatedEmailInput{
-		Source:       awssdk.String(d.SourceAddress),
-		Template:     awssdk.String(d.Providers.SES.TemplateID),
-		TemplateData: awssdk.String(string(dataJSON)),
-		Destination:  &awstypes.Destination{ToAddresses: []string{d.DestinationAddress}},
-	})
+	return newReceipt(p.Name(), awssdk.ToString(out.MessageId)), nil
+}
+
+// buildContent returns a server-side template reference, or a raw MIME message
+// when the policy asks for local rendering.
+func (p *SESProvider) buildContent(d *types.EmailData) (*awstypes.EmailContent, error) {
+	pd := d.Providers.SES
+
+	if pd.Rendering == types.RenderingLocal {
+		rendered, err := p.Renderer.Render(pd)
+		if err != nil {
+			return nil, err
+		}
+		// bcc recipients only go into the envelope, not the headers
+		to, cc, _ := Recipients(d)
+		msg, err := templates.BuildMIMEMessage(templates.Header{
+			From:    d.SourceAddress,
+			To:      to,
+			Cc:      cc,
+			ReplyTo: d.ReplyToAddresses,
+		}, rendered)
+		if err != nil {
+			return nil, fmt.Errorf("error building mime message: %w", err)
+		}
+		return &awstypes.EmailContent{Raw: &awstypes.RawMessage{Data: msg}}, nil
+	}
+
+	dataJSON, err := json.Marshal(pd.TemplateData)
 	if err != nil {
-		return fmt.Errorf("error sending templated email: %w", err)
+		return nil, fmt.Errorf("error marshaling template data: %w", err)
 	}
 
-	return nil
+	return &awstypes.EmailContent{
+		Template: &awstypes.Template{
+			TemplateName: awssdk.String(pd.TemplateID),
+			TemplateData: awssdk.String(string(dataJSON)),
+		},
+	}, nil
 }
 
 func (p *SESProvider) SendDryRun(ctx context.Context, d *types.EmailData) error {
-	dataJSON, err := json.Marshal(d.Providers.SES.TemplateData)
+	dataJSON, err := json.Marshal(deadletter.WithoutCodeData(d.Providers.SES.TemplateData))
 	if err != nil {
 		return fmt.Errorf("error marshaling template data: %w", err)
 	}
@@ -73,8 +138,13 @@ func (p *SESProvider) SendDryRun(ctx context.Context, d *types.EmailData) error
 	slog.DebugContext(ctx, "dry-run ses send",
 		"template_id", d.Providers.SES.TemplateID,
 		"template_data", string(dataJSON),
+		"rendering", d.Providers.SES.Rendering,
+		"configuration_set", d.Providers.SES.ConfigurationSet,
+		"tags", d.Providers.SES.Tags,
 		"src_address", d.SourceAddress,
 		"dst_address", d.DestinationAddress,
+		"cc_count", len(d.CcAddresses),
+		"bcc_count", len(d.BccAddresses),
 	)
 
 	return nil
@@ -89,3 +159,25 @@ func (p *SESProvider) IsHealthy(ctx context.Context) bool {
 	}
 	return p.healthChecker.IsHealthy(ctx)
 }
+
+// messageTags converts policy tags to SES message tags in a stable order.
+func messageTags(tags map[string]string) []awstypes.MessageTag {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]awstypes.MessageTag, 0, len(names))
+	for _, name := range names {
+		out = append(out, awstypes.MessageTag{
+			Name:  awssdk.String(name),
+			Value: awssdk.String(tags[name]),
+		})
+	}
+	return out
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // SESHealthChecker checks AWS SES account status to determine if sending is enabled.
@@ -54,7 +56,12 @@ func (h *SESHealthChecker) IsHealthy(ctx context.Context) bool {
 
 // checkHealth calls the SES GetAccount API to determine if sending is enabled.
 func (h *SESHealthChecker) checkHealth(ctx context.Context) bool {
+	ctx, span := tracing.Start(ctx, "SESHealthChecker.checkHealth")
 	output, err := h.client.GetAccount(ctx, &sesv2.GetAccountInput{})
+	if err == nil {
+		span.SetAttributes(attribute.Bool("ses.sending_enabled", output.SendingEnabled))
+	}
+	tracing.End(span, err)
 	if err != nil {
 		slog.WarnContext(ctx, "ses health check failed", "error", err)
 		// On API error, assume unhealthy to trigger failover
@@ -0,0 +1,186 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// mockSESClient records the SendEmail input for testing
+type mockSESClient struct {
+	input *sesv2.SendEmailInput
+	err   error
+}
+
+func (m *mockSESClient) SendEmail(ctx context.Context, input *sesv2.SendEmailInput, opts ...func(*sesv2.Options)) (*sesv2.SendEmailOutput, error) {
+	m.input = input
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sesv2.SendEmailOutput{MessageId: awssdk.String("ses-message-id")}, nil
+}
+
+func newTestSESEmailData() *types.EmailData {
+	return &types.EmailData{
+		SourceAddress:      "ACME <noreply@example.com>",
+		DestinationAddress: "user@example.com",
+		ReplyToAddresses:   []string{"support@example.com"},
+		VerificationCode:   "123456",
+		Providers: &types.EmailProviderMap{
+			SES: &types.EmailProviderData{
+				TemplateID:                "welcome",
+				TemplateData:              map[string]any{"appName": "ACME"},
+				ConfigurationSet:          "tenant-a",
+				Tags:                      map[string]string{"tenant": "a", "app": "acme"},
+				FeedbackForwardingAddress: "bounces@example.com",
+			},
+		},
+	}
+}
+
+func TestSESProvider_SendTemplate(t *testing.T) {
+	client := &mockSESClient{}
+	p := &SESProvider{Client: client}
+
+	receipt, err := p.Send(context.Background(), newTestSESEmailData())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if receipt.Provider != "ses" || receipt.MessageID != "ses-message-id" || receipt.Attempts != 1 {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+
+	in := client.input
+	if in == nil {
+		t.Fatal("expected SendEmail to be called")
+	}
+	if in.Content.Template == nil || *in.Content.Template.TemplateName != "welcome" {
+		t.Fatalf("expected template content 'welcome', got %+v", in.Content)
+	}
+	if !strings.Contains(*in.Content.Template.TemplateData, `"code":"123456"`) {
+		t.Errorf("expected template data to include code, got %s", *in.Content.Template.TemplateData)
+	}
+	if in.ConfigurationSetName == nil || *in.ConfigurationSetName != "tenant-a" {
+		t.Errorf("expected configuration set 'tenant-a', got %v", in.ConfigurationSetName)
+	}
+	if in.FeedbackForwardingEmailAddress == nil || *in.FeedbackForwardingEmailAddress != "bounces@example.com" {
+		t.Errorf("expected feedback forwarding address, got %v", in.FeedbackForwardingEmailAddress)
+	}
+	if len(in.ReplyToAddresses) != 1 || in.ReplyToAddresses[0] != "support@example.com" {
+		t.Errorf("expected reply-to addresses, got %v", in.ReplyToAddresses)
+	}
+	if len(in.EmailTags) != 2 || *in.EmailTags[0].Name != "app" || *in.EmailTags[1].Name != "tenant" {
+		t.Errorf("expected sorted message tags, got %+v", in.EmailTags)
+	}
+}
+
+func TestSESProvider_SendLocalRendering(t *testing.T) {
+	client := &mockSESClient{}
+	p := &SESProvider{Client: client}
+
+	d := newTestSESEmailData()
+	d.Providers.SES.Rendering = types.RenderingLocal
+	d.Providers.SES.Subject = "{{.appName}} code"
+	d.Providers.SES.Text = "Your code is {{.code}}"
+
+	if _, err := p.Send(context.Background(), d); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	in := client.input
+	if in.Content.Raw == nil {
+		t.Fatalf("expected raw content, got %+v", in.Content)
+	}
+	raw := string(in.Content.Raw.Data)
+	if !strings.Contains(raw, "Your code is 123456") {
+		t.Errorf("expected rendered body in raw message, got %s", raw)
+	}
+	if !strings.Contains(raw, "Reply-To: <support@example.com>") {
+		t.Errorf("expected reply-to header in raw message, got %s", raw)
+	}
+	if in.ConfigurationSetName == nil || *in.ConfigurationSetName != "tenant-a" {
+		t.Errorf("expected configuration set on raw send, got %v", in.ConfigurationSetName)
+	}
+}
+
+func TestSESProvider_SendError(t *testing.T) {
+	client := &mockSESClient{err: errors.New("throttled")}
+	p := &SESProvider{Client: client}
+
+	_, err := p.Send(context.Background(), newTestSESEmailData())
+	if err == nil || !strings.Contains(err.Error(), "throttled") {
+		t.Fatalf("expected wrapped client error, got: %v", err)
+	}
+}
+
+func TestSESProvider_DryRun(t *testing.T) {
+	client := &mockSESClient{}
+	p := &SESProvider{Client: client, DryRun: true}
+
+	if _, err := p.Send(context.Background(), newTestSESEmailData()); err != nil {
+		t.Fatalf("expected no error in dry-run, got: %v", err)
+	}
+	if client.input != nil {
+		t.Error("expected no SendEmail call in dry-run")
+	}
+}
+
+func TestEmailProviders_SendDryRun_OmitsCode(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	d := newTestSESEmailData()
+	d.Providers.SendGrid = &types.EmailProviderData{TemplateID: "d-123", TemplateData: map[string]any{"appName": "ACME"}}
+	d.Providers.SMTP = newTestSMTPEmailData().Providers.SMTP
+
+	for _, p := range []Provider{
+		&SESProvider{DryRun: true},
+		&SendGridProvider{DryRun: true},
+		&SMTPProvider{DryRun: true},
+	} {
+		if _, err := p.Send(context.Background(), d); err != nil {
+			t.Fatalf("%s: unexpected error: %v", p.Name(), err)
+		}
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "123456") {
+		t.Errorf("expected code to be kept out of dry-run logs without redaction, got %s", out)
+	}
+	if strings.Count(out, "ACME") < 3 {
+		t.Errorf("expected template data in every dry-run log, got %s", out)
+	}
+}
+
+func TestSESProvider_SendCcBcc(t *testing.T) {
+	client := &mockSESClient{}
+	p := &SESProvider{Client: client}
+
+	d := newTestSESEmailData()
+	d.DestinationAddresses = []string{"old@example.com", "USER@example.com"}
+	d.CcAddresses = []string{"manager@example.com"}
+	d.BccAddresses = []string{"compliance@example.com", "manager@example.com"}
+
+	if _, err := p.Send(context.Background(), d); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	dst := client.input.Destination
+	if strings.Join(dst.ToAddresses, ",") != "user@example.com,old@example.com" {
+		t.Errorf("expected deduplicated to addresses, got %v", dst.ToAddresses)
+	}
+	if strings.Join(dst.CcAddresses, ",") != "manager@example.com" {
+		t.Errorf("expected cc addresses, got %v", dst.CcAddresses)
+	}
+	if strings.Join(dst.BccAddresses, ",") != "compliance@example.com" {
+		t.Errorf("expected bcc without recipients already in cc, got %v", dst.BccAddresses)
+	}
+}
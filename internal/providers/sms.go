@@ -0,0 +1,90 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// SMSCodePlaceholder is replaced with the decrypted verification code when an
+// SMS message is rendered. It matches the placeholder Cognito uses in its own
+// message templates.
+const SMSCodePlaceholder = "{####}"
+
+type SMSProvider interface {
+	Name() string
+	SendSMS(ctx context.Context, d *types.SMSData) error
+}
+
+// NewSMSProvider creates an SMS provider based on configuration.
+// If sms failover is enabled, it creates an SMSFailoverProvider with the primary
+// provider and all failover providers in order.
+func NewSMSProvider(cfg *config.Config) (SMSProvider, error) {
+	if cfg.AppSMSFailoverEnabled && len(cfg.AppSMSFailoverProviders) > 0 {
+		return newSMSFailoverProvider(cfg)
+	}
+
+	p, err := createSMSProvider(cfg.AppSMSProvider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewMeteredSMSProvider(p), nil
+}
+
+// newSMSFailoverProvider creates an SMSFailoverProvider with the primary provider
+// first, followed by all configured sms failover providers.
+func newSMSFailoverProvider(cfg *config.Config) (SMSProvider, error) {
+	providerNames := append([]string{cfg.AppSMSProvider}, cfg.AppSMSFailoverProviders...)
+
+	seen := make(map[string]bool)
+	var providers []SMSProvider
+	for _, name := range providerNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		p, err := createSMSProvider(name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sms provider %s: %w", name, err)
+		}
+		providers = append(providers, NewMeteredSMSProvider(p))
+	}
+
+	var opts []SMSFailoverOption
+	if cfg.AppSMSFailoverAllFailedAction != "" {
+		var sink deadletter.Sink
+		if cfg.AppSMSFailoverAllFailedAction == AllFailedDeadLetter {
+			s, err := deadletter.NewSink(cfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create dead-letter sink: %w", err)
+			}
+			sink = s
+		}
+		opts = append(opts, WithSMSAllFailedAction(cfg.AppSMSFailoverAllFailedAction, sink))
+	}
+
+	return NewSMSFailoverProvider(providers, opts...), nil
+}
+
+// createSMSProvider creates a single sms provider by name.
+func createSMSProvider(name string, cfg *config.Config) (SMSProvider, error) {
+	switch name {
+	case "sns":
+		return NewSNSProvider(cfg), nil
+	case "twilio":
+		return NewTwilioProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %s", name)
+	}
+}
+
+// RenderSMSMessage returns the message body with the verification code
+// substituted for SMSCodePlaceholder.
+func RenderSMSMessage(d *types.SMSData) string {
+	return strings.ReplaceAll(d.Message, SMSCodePlaceholder, d.VerificationCode)
+}
@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/metrics"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// SMSFailoverProvider wraps multiple sms providers and attempts to send messages
+// through them in order, failing over to the next provider if one is unhealthy
+// or fails to send.
+type SMSFailoverProvider struct {
+	providers       []SMSProvider
+	allFailedAction string
+	deadLetter      deadletter.Sink
+}
+
+// SMSFailoverOption configures an SMSFailoverProvider.
+type SMSFailoverOption func(*SMSFailoverProvider)
+
+// WithSMSAllFailedAction sets what happens when every sms provider fails or is
+// skipped, with the same actions as WithAllFailedAction.
+func WithSMSAllFailedAction(action string, sink deadletter.Sink) SMSFailoverOption {
+	return func(f *SMSFailoverProvider) {
+		f.allFailedAction = action
+		f.deadLetter = sink
+	}
+}
+
+// NewSMSFailoverProvider creates a new sms failover provider with the given
+// providers. Providers are tried in order - the first healthy provider that
+// successfully sends the message wins.
+func NewSMSFailoverProvider(providers []SMSProvider, opts ...SMSFailoverOption) *SMSFailoverProvider {
+	f := &SMSFailoverProvider{
+		providers:       providers,
+		allFailedAction: AllFailedSwallow,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Name returns "failover" to identify this as a failover provider.
+func (f *SMSFailoverProvider) Name() string {
+	return "failover"
+}
+
+// SendSMS attempts to send a message through each provider in order. It follows
+// the same semantics as FailoverProvider.Send: unhealthy providers are skipped,
+// a send failure moves on to the next provider and the all-failed action is
+// applied when none delivered the message.
+func (f *SMSFailoverProvider) SendSMS(ctx context.Context, d *types.SMSData) error {
+	var lastErr error
+
+	for _, p := range f.providers {
+		providerName := p.Name()
+
+		if hc, ok := p.(HealthChecker); ok {
+			if !hc.IsHealthy(ctx) {
+				slog.WarnContext(ctx, "sms provider unhealthy, skipping",
+					"provider", providerName,
+				)
+				metrics.Count(ctx, "FailoverHops", metrics.Dim("Provider", providerName))
+				continue
+			}
+		}
+
+		err := p.SendSMS(ctx, d)
+		if err == nil {
+			slog.InfoContext(ctx, "sms sent successfully",
+				"provider", providerName,
+			)
+			return nil
+		}
+
+		slog.WarnContext(ctx, "sms provider send failed, trying next",
+			"provider", providerName,
+			"error", err,
+		)
+		metrics.Count(ctx, "FailoverHops", metrics.Dim("Provider", providerName))
+		lastErr = err
+	}
+
+	return f.handleAllFailed(ctx, d, lastErr)
+}
+
+// handleAllFailed applies the configured all-failed action, like
+// FailoverProvider.handleAllFailed.
+func (f *SMSFailoverProvider) handleAllFailed(ctx context.Context, d *types.SMSData, lastErr error) error {
+	metrics.Count(ctx, "AllProvidersFailed", metrics.Dim("Channel", "sms"))
+
+	err := ErrAllProvidersFailed
+	if lastErr != nil {
+		err = fmt.Errorf("%w: %w", ErrAllProvidersFailed, lastErr)
+		slog.WarnContext(ctx, "all sms providers failed to send message",
+			"last_error", lastErr,
+			"destination", d.DestinationPhoneNumber,
+			"action", f.allFailedAction,
+		)
+	} else {
+		slog.WarnContext(ctx, "no sms providers available to send message",
+			"destination", d.DestinationPhoneNumber,
+			"action", f.allFailedAction,
+		)
+	}
+
+	switch f.allFailedAction {
+	case AllFailedError:
+		return err
+	case AllFailedDeadLetter:
+		names := make([]string, len(f.providers))
+		for i, p := range f.providers {
+			names[i] = p.Name()
+		}
+		msg := deadletter.NewSMSMessage(ctx, d, names, err)
+		if dlErr := f.deadLetter.Publish(ctx, msg); dlErr != nil {
+			return fmt.Errorf("%w: dead-letter publish failed: %w", err, dlErr)
+		}
+		slog.InfoContext(ctx, "undelivered sms published to dead-letter sink",
+			"sink", f.deadLetter.Name(),
+			"destination", d.DestinationPhoneNumber,
+		)
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Providers returns the list of providers in this failover chain.
+func (f *SMSFailoverProvider) Providers() []SMSProvider {
+	return f.providers
+}
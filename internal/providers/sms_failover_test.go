@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// mockSMSProvider is a test sms provider that can be configured to fail or succeed
+type mockSMSProvider struct {
+	name      string
+	sendErr   error
+	healthy   bool
+	sendCount int
+	mu        sync.Mutex
+}
+
+func (m *mockSMSProvider) Name() string {
+	return m.name
+}
+
+func (m *mockSMSProvider) SendSMS(ctx context.Context, d *types.SMSData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sendCount++
+	return m.sendErr
+}
+
+func (m *mockSMSProvider) IsHealthy(ctx context.Context) bool {
+	return m.healthy
+}
+
+func (m *mockSMSProvider) GetSendCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sendCount
+}
+
+func newTestSMSData() *types.SMSData {
+	return &types.SMSData{
+		DestinationPhoneNumber: "+15555550100",
+		Message:                "Your code is {####}",
+		VerificationCode:       "123456",
+	}
+}
+
+func TestSMSFailoverProvider_SendsToFirstHealthyProvider(t *testing.T) {
+	primary := &mockSMSProvider{name: "sns", healthy: true}
+	secondary := &mockSMSProvider{name: "twilio", healthy: true}
+
+	fp := NewSMSFailoverProvider([]SMSProvider{primary, secondary})
+
+	if err := fp.SendSMS(context.Background(), newTestSMSData()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if primary.GetSendCount() != 1 {
+		t.Errorf("expected primary to be called once, got %d", primary.GetSendCount())
+	}
+	if secondary.GetSendCount() != 0 {
+		t.Errorf("expected secondary to not be called, got %d", secondary.GetSendCount())
+	}
+}
+
+func TestSMSFailoverProvider_SkipsUnhealthyProvider(t *testing.T) {
+	primary := &mockSMSProvider{name: "sns", healthy: false}
+	secondary := &mockSMSProvider{name: "twilio", healthy: true}
+
+	fp := NewSMSFailoverProvider([]SMSProvider{primary, secondary})
+
+	if err := fp.SendSMS(context.Background(), newTestSMSData()); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if primary.GetSendCount() != 0 {
+		t.Errorf("expected primary to be skipped (unhealthy), got %d calls", primary.GetSendCount())
+	}
+	if secondary.GetSendCount() != 1 {
+		t.Errorf("expected secondary to be called once, got %d", secondary.GetSendCount())
+	}
+}
+
+func TestSMSFailoverProvider_FailsOverOnSendError(t *testing.T) {
+	primary := &mockSMSProvider{name: "sns", healthy: true, sendErr: errors.New("send failed")}
+	secondary := &mockSMSProvider{name: "twilio", healthy: true}
+
+	fp := NewSMSFailoverProvider([]SMSProvider{primary, secondary})
+
+	if err := fp.SendSMS(context.Background(), newTestSMSData()); err != nil {
+		t.Fatalf("expected no error (should failover), got: %v", err)
+	}
+	if primary.GetSendCount() != 1 {
+		t.Errorf("expected primary to be called once, got %d", primary.GetSendCount())
+	}
+	if secondary.GetSendCount() != 1 {
+		t.Errorf("expected secondary to be called once, got %d", secondary.GetSendCount())
+	}
+}
+
+func TestSMSFailoverProvider_WarnsAndReturnsNilWhenAllFail(t *testing.T) {
+	primary := &mockSMSProvider{name: "sns", healthy: true, sendErr: errors.New("primary failed")}
+	secondary := &mockSMSProvider{name: "twilio", healthy: true, sendErr: errors.New("secondary failed")}
+
+	fp := NewSMSFailoverProvider([]SMSProvider{primary, secondary})
+
+	if err := fp.SendSMS(context.Background(), newTestSMSData()); err != nil {
+		t.Fatalf("expected nil error (warns only), got: %v", err)
+	}
+	if primary.GetSendCount() != 1 || secondary.GetSendCount() != 1 {
+		t.Errorf("expected both providers to be called once, got %d and %d", primary.GetSendCount(), secondary.GetSendCount())
+	}
+}
+
+func newAllFailingSMSProviders() []SMSProvider {
+	return []SMSProvider{
+		&mockSMSProvider{name: "sns", healthy: true, sendErr: errors.New("primary failed")},
+		&mockSMSProvider{name: "twilio", healthy: true, sendErr: errors.New("secondary failed")},
+	}
+}
+
+func TestSMSFailoverProvider_AllFailedError(t *testing.T) {
+	fp := NewSMSFailoverProvider(newAllFailingSMSProviders(), WithSMSAllFailedAction(AllFailedError, nil))
+
+	err := fp.SendSMS(context.Background(), newTestSMSData())
+	if !errors.Is(err, ErrAllProvidersFailed) {
+		t.Fatalf("expected ErrAllProvidersFailed, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "secondary failed") {
+		t.Errorf("expected last provider error to be wrapped, got: %v", err)
+	}
+}
+
+func TestSMSFailoverProvider_AllFailedDeadLetter(t *testing.T) {
+	sink := &mockDeadLetterSink{}
+	fp := NewSMSFailoverProvider(newAllFailingSMSProviders(), WithSMSAllFailedAction(AllFailedDeadLetter, sink))
+
+	if err := fp.SendSMS(context.Background(), newTestSMSData()); err != nil {
+		t.Fatalf("expected no error after dead-letter publish, got: %v", err)
+	}
+	if len(sink.messages) != 1 {
+		t.Fatalf("expected 1 dead-letter message, got %d", len(sink.messages))
+	}
+	msg := sink.messages[0]
+	if len(msg.Providers) != 2 || msg.Providers[1] != "twilio" {
+		t.Errorf("expected attempted providers to be recorded, got %v", msg.Providers)
+	}
+	if msg.SMS == nil || msg.SMS.DestinationPhoneNumber != "+15555550100" || msg.Email != nil {
+		t.Fatalf("expected sms dead-letter message, got %+v", msg)
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "123456") {
+		t.Errorf("expected plaintext code to be omitted, got %s", body)
+	}
+}
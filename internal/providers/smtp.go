@@ -0,0 +1,201 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/templates"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// SMTPProvider delivers email to an SMTP relay. SMTP has no server-side
+// templates, so the subject and bodies are always rendered locally from the
+// stored templates and the policy's provider data.
+type SMTPProvider struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	TLSMode   string // starttls, tls or none
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+	Renderer  *templates.Renderer
+	DryRun    bool
+}
+
+func NewSMTPProvider(cfg *config.Config, renderer *templates.Renderer) *SMTPProvider {
+	return &SMTPProvider{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		TLSMode:  cfg.SMTPTLSMode,
+		Timeout:  10 * time.Second,
+		Renderer: renderer,
+		DryRun:   !cfg.AppSendEnabled,
+	}
+}
+
+func (p *SMTPProvider) Name() string {
+	return "smtp"
+}
+
+func (p *SMTPProvider) Send(ctx context.Context, d *types.EmailData) (*Receipt, error) {
+	d.Providers.SMTP.TemplateData = MergeTemplateData(d.Providers.SMTP.TemplateData, map[string]any{"code": d.VerificationCode})
+
+	rendered, err := p.Renderer.Render(d.Providers.SMTP)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.DryRun {
+		if err := p.SendDryRun(ctx, d, rendered); err != nil {
+			return nil, err
+		}
+		return newReceipt(p.Name(), ""), nil
+	}
+
+	_, srcAddr := ParseNameAddr(d.SourceAddress)
+	to, cc, bcc := Recipients(d)
+
+	// bcc recipients only go into the envelope, not the headers
+	msg, err := templates.BuildMIMEMessage(templates.Header{
+		From:    d.SourceAddress,
+		To:      to,
+		Cc:      cc,
+		ReplyTo: d.ReplyToAddresses,
+	}, rendered)
+	if err != nil {
+		return nil, fmt.Errorf("error building mime message: %w", err)
+	}
+
+	c, err := p.dial(ctx)
+	if err != nil {
+		return nil, classifySMTPError(fmt.Errorf("smtp connect error: %w", err), false)
+	}
+	defer c.Close()
+
+	if p.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			// a relay without auth will not gain it between retries, but
+			// another provider can still send the message
+			return nil, classifySMTPError(errors.New("smtp server does not support authentication"), false)
+		}
+		if err := c.Auth(smtp.PlainAuth("", p.Username, p.Password, p.Host)); err != nil {
+			return nil, classifySMTPError(fmt.Errorf("smtp auth error: %w", err), false)
+		}
+	}
+
+	if err := c.Mail(srcAddr); err != nil {
+		return nil, classifySMTPError(fmt.Errorf("smtp mail from error: %w", err), false)
+	}
+	for _, rcpt := range slices.Concat(to, cc, bcc) {
+		_, addr := ParseNameAddr(rcpt)
+		if err := c.Rcpt(addr); err != nil {
+			return nil, classifySMTPError(fmt.Errorf("smtp rcpt to error: %w", err), true)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return nil, classifySMTPError(fmt.Errorf("smtp data error: %w", err), false)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return nil, classifySMTPError(fmt.Errorf("smtp write error: %w", err), false)
+	}
+	if err := w.Close(); err != nil {
+		return nil, classifySMTPError(fmt.Errorf("smtp send failed: %w", err), false)
+	}
+
+	// the server accepted the message once the data is closed, so a failed
+	// quit must not trigger a retry or failover that would deliver it twice
+	if err := c.Quit(); err != nil {
+		slog.WarnContext(ctx, "smtp quit failed after message was accepted", "host", p.Host, "error", err)
+	}
+
+	return newReceipt(p.Name(), messageID(msg)), nil
+}
+
+func (p *SMTPProvider) SendDryRun(ctx context.Context, d *types.EmailData, rendered *templates.Email) error {
+	slog.DebugContext(ctx, "dry-run smtp send",
+		"host", p.Host,
+		"subject", rendered.Subject,
+		"template_data", deadletter.WithoutCodeData(d.Providers.SMTP.TemplateData),
+		"src_address", d.SourceAddress,
+		"dst_address", d.DestinationAddress,
+		"cc_count", len(d.CcAddresses),
+		"bcc_count", len(d.BccAddresses),
+	)
+	return nil
+}
+
+// dial opens a connection to the smtp server and negotiates tls according to
+// the configured mode.
+func (p *SMTPProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	dialer := &net.Dialer{Timeout: p.Timeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else if p.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(p.Timeout))
+	}
+
+	if p.TLSMode == "tls" {
+		conn = tls.Client(conn, p.tlsConfig())
+	}
+
+	c, err := smtp.NewClient(conn, p.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if p.TLSMode == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close()
+			return nil, fmt.Errorf("smtp server does not support STARTTLS")
+		}
+		if err := c.StartTLS(p.tlsConfig()); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// messageID returns the Message-ID header of msg without its angle brackets,
+// since SMTP servers do not return an ID of their own.
+func messageID(msg []byte) string {
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		return ""
+	}
+	return strings.Trim(m.Header.Get("Message-ID"), "<>")
+}
+
+func (p *SMTPProvider) tlsConfig() *tls.Config {
+	if p.TLSConfig != nil {
+		return p.TLSConfig
+	}
+	return &tls.Config{ServerName: p.Host, MinVersion: tls.VersionTLS12}
+}
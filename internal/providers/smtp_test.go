@@ -0,0 +1,351 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/audit"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// fakeSMTPServer is a minimal SMTP stand-in that accepts a single message per
+// connection and records the envelope and data it receives.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	// quitFails makes the server reject QUIT after accepting a message
+	quitFails bool
+	// rcptRejects makes the server reject every recipient, echoing its address
+	rcptRejects bool
+	// noAuth makes the server not advertise the AUTH extension
+	noAuth bool
+
+	mu       sync.Mutex
+	authed   bool
+	mailFrom string
+	rcptTo   []string
+	data     string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	s := &fakeSMTPServer{listener: l}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+
+	return s
+}
+
+func (s *fakeSMTPServer) hostPort(t *testing.T) (string, int) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(s.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake.smtp ESMTP ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"):
+			if s.noAuth {
+				reply("250 fake.smtp")
+				continue
+			}
+			reply("250-fake.smtp")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(upper, "AUTH PLAIN"):
+			s.mu.Lock()
+			s.authed = true
+			s.mu.Unlock()
+			reply("235 authenticated")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.mu.Lock()
+			s.mailFrom = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			s.mu.Unlock()
+			reply("250 ok")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			if s.rcptRejects {
+				reply("550 5.1.1 " + strings.TrimSpace(cmd[len("RCPT TO:"):]) + ": recipient address rejected")
+				continue
+			}
+			s.mu.Lock()
+			s.rcptTo = append(s.rcptTo, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			s.mu.Unlock()
+			reply("250 ok")
+		case upper == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				dl, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dl == ".\r\n" {
+					break
+				}
+				b.WriteString(dl)
+			}
+			s.mu.Lock()
+			s.data = b.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case upper == "QUIT":
+			if s.quitFails {
+				reply("421 service not available")
+				return
+			}
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func newTestSMTPEmailData() *types.EmailData {
+	return &types.EmailData{
+		SourceAddress:      "ACME <noreply@example.com>",
+		DestinationAddress: "user@example.com",
+		VerificationCode:   "123456",
+		Providers: &types.EmailProviderMap{
+			SMTP: &types.EmailProviderData{
+				TemplateData: map[string]any{"appName": "ACME"},
+				Subject:      "{{.appName}} verification code",
+				Text:         "Your code is {{.code}}",
+				HTML:         "<p>Your code is <b>{{.code}}</b></p>",
+			},
+		},
+	}
+}
+
+func TestSMTPProvider_Send(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	p := &SMTPProvider{
+		Host:     host,
+		Port:     port,
+		Username: "user",
+		Password: "pass",
+		TLSMode:  "none",
+	}
+
+	receipt, err := p.Send(context.Background(), newTestSMTPEmailData())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if !server.authed {
+		t.Error("expected client to authenticate")
+	}
+	if server.mailFrom != "noreply@example.com" {
+		t.Errorf("expected MAIL FROM 'noreply@example.com', got '%s'", server.mailFrom)
+	}
+	if len(server.rcptTo) != 1 || server.rcptTo[0] != "user@example.com" {
+		t.Errorf("expected RCPT TO 'user@example.com', got %v", server.rcptTo)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("failed to parse delivered message: %v", err)
+	}
+	if subject := msg.Header.Get("Subject"); !strings.Contains(subject, "ACME") {
+		t.Errorf("expected rendered subject, got '%s'", subject)
+	}
+	if !strings.HasPrefix(msg.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("expected multipart/alternative, got '%s'", msg.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(server.data, "Your code is 123456") {
+		t.Error("expected text body to contain the verification code")
+	}
+	if !strings.Contains(server.data, "<b>123456</b>") {
+		t.Error("expected html body to contain the verification code")
+	}
+	if "<"+receipt.MessageID+">" != msg.Header.Get("Message-ID") {
+		t.Errorf("expected receipt message id to match header %s, got %s", msg.Header.Get("Message-ID"), receipt.MessageID)
+	}
+}
+
+func TestSMTPProvider_QuitErrorAfterAccept(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.quitFails = true
+	host, port := server.hostPort(t)
+
+	p := &SMTPProvider{Host: host, Port: port, TLSMode: "none"}
+
+	receipt, err := p.Send(context.Background(), newTestSMTPEmailData())
+	if err != nil {
+		t.Fatalf("expected accepted message to count as sent, got: %v", err)
+	}
+	if receipt == nil || receipt.MessageID == "" {
+		t.Errorf("expected receipt with message id, got %+v", receipt)
+	}
+}
+
+func TestSMTPProvider_RcptRejectedKeepsAddressOutOfAudit(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.rcptRejects = true
+	host, port := server.hostPort(t)
+
+	rec := audit.NewRecord("email", "pool", "sub", "CustomEmailSender_SignUp", "client")
+	ctx := audit.NewContext(context.Background(), rec)
+
+	p := NewMeteredProvider(&SMTPProvider{Host: host, Port: port, TLSMode: "none"})
+	_, err := p.Send(ctx, newTestSMTPEmailData())
+	if err == nil || !strings.Contains(err.Error(), "user@example.com") {
+		t.Fatalf("expected rcpt error echoing the address, got: %v", err)
+	}
+	rec.Finish(err)
+
+	if len(rec.Attempts) != 1 {
+		t.Fatalf("expected 1 attempt, got %+v", rec.Attempts)
+	}
+	if a := rec.Attempts[0]; a.ErrorClass != "permanent" || a.StatusCode != 550 {
+		t.Errorf("expected permanent 550 attempt, got %+v", a)
+	}
+
+	out, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "user@example.com") {
+		t.Errorf("expected recipient address to be absent from audit record, got %s", out)
+	}
+}
+
+func TestSMTPProvider_AuthUnsupported(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	server.noAuth = true
+	host, port := server.hostPort(t)
+
+	p := &SMTPProvider{Host: host, Port: port, Username: "user", Password: "pass", TLSMode: "none"}
+	_, err := p.Send(context.Background(), newTestSMTPEmailData())
+	if err == nil || !strings.Contains(err.Error(), "does not support authentication") {
+		t.Fatalf("expected auth unsupported error, got: %v", err)
+	}
+
+	var pe *ProviderError
+	if !errors.As(err, &pe) || pe.Provider != "smtp" || pe.Class != ErrorClassProvider {
+		t.Errorf("expected smtp provider error, got %#v", err)
+	}
+	if IsRetryable(err) {
+		t.Error("expected auth unsupported not to be retried")
+	}
+}
+
+func TestSMTPProvider_StartTLSRequired(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	p := &SMTPProvider{Host: host, Port: port, TLSMode: "starttls"}
+
+	_, err := p.Send(context.Background(), newTestSMTPEmailData())
+	if err == nil {
+		t.Fatal("expected error when server does not advertise STARTTLS, got nil")
+	}
+	if !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected STARTTLS error, got: %v", err)
+	}
+}
+
+func TestSMTPProvider_DryRunSkipsConnection(t *testing.T) {
+	p := &SMTPProvider{Host: "127.0.0.1", Port: 1, TLSMode: "none", DryRun: true}
+
+	if _, err := p.Send(context.Background(), newTestSMTPEmailData()); err != nil {
+		t.Fatalf("expected no error in dry-run, got: %v", err)
+	}
+}
+
+func TestSMTPProvider_MissingTemplates(t *testing.T) {
+	p := &SMTPProvider{DryRun: true}
+	d := newTestSMTPEmailData()
+	d.Providers.SMTP.HTML = ""
+	d.Providers.SMTP.Text = ""
+
+	if _, err := p.Send(context.Background(), d); err == nil {
+		t.Fatal("expected error when no body templates are set, got nil")
+	}
+}
+
+func TestSMTPProvider_SendCcBcc(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort(t)
+
+	p := &SMTPProvider{Host: host, Port: port, TLSMode: "none"}
+
+	d := newTestSMTPEmailData()
+	d.DestinationAddresses = []string{"old@example.com"}
+	d.CcAddresses = []string{"manager@example.com"}
+	d.BccAddresses = []string{"Compliance <compliance@example.com>"}
+
+	if _, err := p.Send(context.Background(), d); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	expected := []string{"user@example.com", "old@example.com", "manager@example.com", "compliance@example.com"}
+	if strings.Join(server.rcptTo, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected RCPT TO %v, got %v", expected, server.rcptTo)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatalf("failed to parse delivered message: %v", err)
+	}
+	if to := msg.Header.Get("To"); to != "<user@example.com>, <old@example.com>" {
+		t.Errorf("expected both destinations in To header, got '%s'", to)
+	}
+	if cc := msg.Header.Get("Cc"); cc != "<manager@example.com>" {
+		t.Errorf("expected Cc header, got '%s'", cc)
+	}
+	if strings.Contains(server.data, "compliance@example.com") {
+		t.Error("expected bcc recipient to be absent from the message headers")
+	}
+}
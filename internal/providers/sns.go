@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// SNSAPI is the subset of the SNS client used to send sms messages.
+type SNSAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+type SNSProvider struct {
+	Client SNSAPI
+	DryRun bool
+}
+
+func NewSNSProvider(cfg *config.Config) *SNSProvider {
+	return &SNSProvider{
+		Client: sns.NewFromConfig(*cfg.AWSConfig),
+		DryRun: !cfg.AppSendEnabled,
+	}
+}
+
+func (p *SNSProvider) Name() string {
+	return "sns"
+}
+
+func (p *SNSProvider) SendSMS(ctx context.Context, d *types.SMSData) error {
+	if p.DryRun {
+		return p.SendDryRun(ctx, d)
+	}
+
+	attrs := map[string]snstypes.MessageAttributeValue{
+		"AWS.SNS.SMS.SMSType": {
+			DataType:    awssdk.String("String"),
+			StringValue: awssdk.String("Transactional"),
+		},
+	}
+	if d.SenderID != "" {
+		attrs["AWS.SNS.SMS.SenderID"] = snstypes.MessageAttributeValue{
+			DataType:    awssdk.String("String"),
+			StringValue: awssdk.String(d.SenderID),
+		}
+	}
+	if d.SourcePhoneNumber != "" {
+		attrs["AWS.MM.SMS.OriginationNumber"] = snstypes.MessageAttributeValue{
+			DataType:    awssdk.String("String"),
+			StringValue: awssdk.String(d.SourcePhoneNumber),
+		}
+	}
+
+	_, err := p.Client.Publish(ctx, &sns.PublishInput{
+		PhoneNumber:       awssdk.String(d.DestinationPhoneNumber),
+		Message:           awssdk.String(RenderSMSMessage(d)),
+		MessageAttributes: attrs,
+	})
+	if err != nil {
+		return classifySNSError(fmt.Errorf("error publishing sms: %w", err))
+	}
+
+	return nil
+}
+
+func (p *SNSProvider) SendDryRun(ctx context.Context, d *types.SMSData) error {
+	slog.DebugContext(ctx, "dry-run sns send",
+		"message_template", d.Message,
+		"sender_id", d.SenderID,
+		"src_phone_number", d.SourcePhoneNumber,
+		"dst_phone_number", d.DestinationPhoneNumber,
+	)
+	return nil
+}
@@ -0,0 +1,75 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// mockSNSClient records the Publish input for testing
+type mockSNSClient struct {
+	input *sns.PublishInput
+	err   error
+}
+
+func (m *mockSNSClient) Publish(ctx context.Context, input *sns.PublishInput, opts ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	m.input = input
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sns.PublishOutput{MessageId: awssdk.String("sns-message-id")}, nil
+}
+
+func TestSNSProvider_SendSMS(t *testing.T) {
+	client := &mockSNSClient{}
+	p := &SNSProvider{Client: client}
+
+	err := p.SendSMS(context.Background(), &types.SMSData{
+		DestinationPhoneNumber: "+15555550100",
+		SenderID:               "ACME",
+		Message:                "Your code is {####}",
+		VerificationCode:       "123456",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	in := client.input
+	if in == nil {
+		t.Fatal("expected Publish to be called")
+	}
+	if *in.PhoneNumber != "+15555550100" || *in.Message != "Your code is 123456" {
+		t.Errorf("unexpected publish input: %s %s", *in.PhoneNumber, *in.Message)
+	}
+	if v := in.MessageAttributes["AWS.SNS.SMS.SenderID"].StringValue; v == nil || *v != "ACME" {
+		t.Errorf("expected sender id attribute, got %v", v)
+	}
+	if _, ok := in.MessageAttributes["AWS.MM.SMS.OriginationNumber"]; ok {
+		t.Error("expected no origination number without a source phone number")
+	}
+}
+
+func TestSNSProvider_SendSMS_ClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorClass
+	}{
+		{"throttled", &mockAPIError{code: "Throttled", status: 400}, ErrorClassRetryable},
+		{"unavailable", &mockAPIError{code: "Unknown", status: 503}, ErrorClassRetryable},
+		{"invalid parameter", &mockAPIError{code: "InvalidParameter", status: 400}, ErrorClassProvider},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &SNSProvider{Client: &mockSNSClient{err: tc.err}}
+			err := p.SendSMS(context.Background(), &types.SMSData{DestinationPhoneNumber: "+15555550100"})
+			if got := ClassOf(err); got != tc.expected {
+				t.Errorf("expected %s, got %s (%v)", tc.expected, got, err)
+			}
+		})
+	}
+}
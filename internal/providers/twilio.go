@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// TwilioProvider sends SMS messages through the Twilio Programmable Messaging
+// REST API. Any service exposing the same API shape can be used by pointing
+// APIHost at it.
+type TwilioProvider struct {
+	Client     *http.Client
+	APIHost    string
+	AccountSid string
+	AuthToken  string
+	DryRun     bool
+}
+
+type twilioMessageResponse struct {
+	Sid     string `json:"sid"`
+	Status  string `json:"status"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewTwilioProvider(cfg *config.Config) *TwilioProvider {
+	return &TwilioProvider{
+		Client:     &http.Client{Timeout: 10 * time.Second},
+		APIHost:    cfg.TwilioApiHost,
+		AccountSid: cfg.TwilioAccountSid,
+		AuthToken:  cfg.TwilioAuthToken,
+		DryRun:     !cfg.AppSendEnabled,
+	}
+}
+
+func (p *TwilioProvider) Name() string {
+	return "twilio"
+}
+
+func (p *TwilioProvider) SendSMS(ctx context.Context, d *types.SMSData) error {
+	if p.DryRun {
+		return p.SendDryRun(ctx, d)
+	}
+
+	from := d.SourcePhoneNumber
+	if from == "" {
+		from = d.SenderID
+	}
+	if from == "" {
+		return fmt.Errorf("twilio requires a source phone number or sender id")
+	}
+
+	form := url.Values{}
+	form.Set("To", d.DestinationPhoneNumber)
+	form.Set("From", from)
+	form.Set("Body", RenderSMSMessage(d))
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(p.APIHost, "/"), url.PathEscape(p.AccountSid))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build twilio request: %w", err)
+	}
+	req.SetBasicAuth(p.AccountSid, p.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		err = fmt.Errorf("twilio api error: %w", err)
+		return &ProviderError{Provider: "twilio", Class: classifyTransportError(err), Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to read twilio response: %w", err)
+		return &ProviderError{Provider: "twilio", Class: classifyTransportError(err), Err: err}
+	}
+
+	// error responses carry a twilio error code in the same shape
+	var payload twilioMessageResponse
+	jsonErr := json.Unmarshal(body, &payload)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return classifyTwilioError(resp.StatusCode, payload.Code,
+			fmt.Errorf("twilio send failed: status=%d body=%s", resp.StatusCode, body))
+	}
+	if jsonErr != nil {
+		return fmt.Errorf("twilio unmarshal error: %w", jsonErr)
+	}
+
+	slog.DebugContext(ctx, "sms accepted by twilio",
+		"message_sid", payload.Sid,
+		"status", payload.Status,
+	)
+	return nil
+}
+
+func (p *TwilioProvider) SendDryRun(ctx context.Context, d *types.SMSData) error {
+	slog.DebugContext(ctx, "dry-run twilio send",
+		"message_template", d.Message,
+		"sender_id", d.SenderID,
+		"src_phone_number", d.SourcePhoneNumber,
+		"dst_phone_number", d.DestinationPhoneNumber,
+	)
+	return nil
+}
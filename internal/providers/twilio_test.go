@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/logging"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+func TestTwilioProvider_SendSMS(t *testing.T) {
+	var gotForm map[string]string
+	var gotUser, gotPass string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if r.Method != "POST" {
+			t.Errorf("unexpected method: %s", r.Method)
+		}
+		gotUser, gotPass, _ = r.BasicAuth()
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("failed to parse form: %v", err)
+		}
+		gotForm = map[string]string{
+			"To":   r.PostForm.Get("To"),
+			"From": r.PostForm.Get("From"),
+			"Body": r.PostForm.Get("Body"),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(twilioMessageResponse{Sid: "SM123", Status: "queued"})
+	}))
+	defer server.Close()
+
+	p := &TwilioProvider{
+		Client:     server.Client(),
+		APIHost:    server.URL,
+		AccountSid: "AC123",
+		AuthToken:  "secret",
+	}
+
+	err := p.SendSMS(context.Background(), &types.SMSData{
+		DestinationPhoneNumber: "+15555550100",
+		SourcePhoneNumber:      "+15555550199",
+		Message:                "Your code is {####}",
+		VerificationCode:       "123456",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if gotUser != "AC123" || gotPass != "secret" {
+		t.Errorf("expected basic auth AC123/secret, got %s/%s", gotUser, gotPass)
+	}
+	if gotForm["To"] != "+15555550100" {
+		t.Errorf("expected To '+15555550100', got '%s'", gotForm["To"])
+	}
+	if gotForm["From"] != "+15555550199" {
+		t.Errorf("expected From '+15555550199', got '%s'", gotForm["From"])
+	}
+	if gotForm["Body"] != "Your code is 123456" {
+		t.Errorf("expected rendered body, got '%s'", gotForm["Body"])
+	}
+}
+
+func TestTwilioProvider_SendSMS_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":21211,"message":"invalid 'To' phone number"}`))
+	}))
+	defer server.Close()
+
+	p := &TwilioProvider{
+		Client:     server.Client(),
+		APIHost:    server.URL,
+		AccountSid: "AC123",
+		AuthToken:  "secret",
+	}
+
+	err := p.SendSMS(context.Background(), &types.SMSData{
+		DestinationPhoneNumber: "not-a-number",
+		SenderID:               "ACME",
+		Message:                "{####}",
+	})
+	if err == nil {
+		t.Fatal("expected error for non-2xx status, got nil")
+	}
+	if !strings.Contains(err.Error(), "status=400") {
+		t.Errorf("expected status in error, got: %v", err)
+	}
+}
+
+func TestTwilioProvider_SendSMS_ClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected ErrorClass
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{"code":20429,"message":"too many requests"}`, ErrorClassRetryable},
+		{"unavailable", http.StatusServiceUnavailable, `service unavailable`, ErrorClassRetryable},
+		{"invalid recipient", http.StatusBadRequest, `{"code":21211,"message":"invalid 'To' phone number"}`, ErrorClassPermanent},
+		{"unauthorized", http.StatusUnauthorized, `{"code":20003,"message":"authenticate"}`, ErrorClassProvider},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			p := &TwilioProvider{Client: server.Client(), APIHost: server.URL, AccountSid: "AC123"}
+			err := p.SendSMS(context.Background(), &types.SMSData{
+				DestinationPhoneNumber: "+15555550100",
+				SenderID:               "ACME",
+				Message:                "{####}",
+			})
+			if got := ClassOf(err); got != tc.expected {
+				t.Errorf("expected %s, got %s (%v)", tc.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestTwilioProvider_SendSMS_RequiresSource(t *testing.T) {
+	p := &TwilioProvider{APIHost: "http://127.0.0.1:0", AccountSid: "AC123"}
+
+	err := p.SendSMS(context.Background(), &types.SMSData{
+		DestinationPhoneNumber: "+15555550100",
+		Message:                "{####}",
+	})
+	if err == nil {
+		t.Fatal("expected error when no source phone number or sender id, got nil")
+	}
+}
+
+func TestRenderSMSMessage(t *testing.T) {
+	d := &types.SMSData{Message: "Code: {####}. Again: {####}", VerificationCode: "42"}
+	if got := RenderSMSMessage(d); got != "Code: 42. Again: 42" {
+		t.Errorf("unexpected rendered message: %s", got)
+	}
+}
+
+func TestSMSProviders_SendDryRun_OmitsCode(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(logging.NewRedactingHandler(handler)))
+
+	d := &types.SMSData{
+		Message:                "Your code is {####}",
+		VerificationCode:       "123456",
+		SourcePhoneNumber:      "+15550001111",
+		DestinationPhoneNumber: "+15552223333",
+	}
+	for _, p := range []interface {
+		SendDryRun(context.Context, *types.SMSData) error
+	}{&SNSProvider{}, &TwilioProvider{}} {
+		if err := p.SendDryRun(context.Background(), d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "123456") {
+		t.Errorf("expected code to be kept out of dry-run logs, got %s", out)
+	}
+	if strings.Count(out, "Your code is {####}") != 2 {
+		t.Errorf("expected message template in both dry-run logs, got %s", out)
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore.
+type DynamoDBAPI interface {
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+}
+
+// DynamoDBStore keeps counters in a DynamoDB table with a string partition key
+// "pk". Items carry an "expires_at" epoch attribute for DynamoDB TTL.
+type DynamoDBStore struct {
+	Client DynamoDBAPI
+	Table  string
+	now    func() time.Time
+}
+
+func NewDynamoDBStore(cfg *config.Config) *DynamoDBStore {
+	return &DynamoDBStore{
+		Client: dynamodb.NewFromConfig(*cfg.AWSConfig),
+		Table:  cfg.AppRateLimitTable,
+	}
+}
+
+func (s *DynamoDBStore) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	expiresAt := now().Add(ttl).Unix()
+
+	out, err := s.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        awssdk.String(s.Table),
+		Key:              map[string]ddbtypes.AttributeValue{"pk": &ddbtypes.AttributeValueMemberS{Value: key}},
+		UpdateExpression: awssdk.String("ADD #count :one SET expires_at = if_not_exists(expires_at, :expires_at)"),
+		ExpressionAttributeNames: map[string]string{
+			"#count": "count",
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":one":        &ddbtypes.AttributeValueMemberN{Value: "1"},
+			":expires_at": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt, 10)},
+		},
+		ReturnValues: ddbtypes.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error incrementing rate limit counter: %w", err)
+	}
+
+	return countAttribute(out.Attributes)
+}
+
+func (s *DynamoDBStore) Get(ctx context.Context, key string) (int64, error) {
+	out, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            awssdk.String(s.Table),
+		Key:                  map[string]ddbtypes.AttributeValue{"pk": &ddbtypes.AttributeValueMemberS{Value: key}},
+		ProjectionExpression: awssdk.String("#count"),
+		ExpressionAttributeNames: map[string]string{
+			"#count": "count",
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error reading rate limit counter: %w", err)
+	}
+
+	return countAttribute(out.Item)
+}
+
+func countAttribute(item map[string]ddbtypes.AttributeValue) (int64, error) {
+	n, ok := item["count"].(*ddbtypes.AttributeValueMemberN)
+	if !ok {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(n.Value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate limit counter: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in memory. Counters only live as long as the
+// Lambda instance, so it is intended for local runs and tests.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]memoryCounter
+	now      func() time.Time
+}
+
+type memoryCounter struct {
+	value     int64
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]memoryCounter), now: time.Now}
+}
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expiresAt) {
+		c = memoryCounter{expiresAt: now.Add(ttl)}
+	}
+	c.value++
+	s.counters[key] = c
+
+	// drop expired counters so long-lived instances do not grow unbounded
+	for k, v := range s.counters {
+		if now.After(v.expiresAt) {
+			delete(s.counters, k)
+		}
+	}
+
+	return c.value, nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || s.now().After(c.expiresAt) {
+		return 0, nil
+	}
+	return c.value, nil
+}
@@ -0,0 +1,212 @@
+// Package ratelimit counts send requests per recipient, user, client or IP in
+// sliding windows so policies can throttle abuse.
+package ratelimit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// Keys a rule can limit on.
+const (
+	KeyDestination = "destination"
+	KeySub         = "sub"
+	KeyClient      = "client"
+	KeyIP          = "ip"
+)
+
+// Store holds window counters.
+type Store interface {
+	// Increment adds one to the counter at key and returns its new value. The
+	// counter may be dropped after ttl.
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Get returns the counter at key, or zero if it does not exist.
+	Get(ctx context.Context, key string) (int64, error)
+}
+
+// Rule limits requests of a trigger per key value to Limit per Window.
+type Rule struct {
+	Trigger string // trigger source, or "*" for all triggers
+	Key     string
+	Limit   int64
+	Window  time.Duration
+}
+
+// ParseRules parses comma-separated rules of the form
+// trigger:key:limit/window, e.g. CustomEmailSender_ForgotPassword:destination:5/1h.
+func ParseRules(s string) ([]Rule, error) {
+	var rules []Rule
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		parts := strings.Split(raw, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid rate limit rule %q: expected trigger:key:limit/window", raw)
+		}
+		trigger, key, spec := parts[0], parts[1], parts[2]
+
+		switch key {
+		case KeyDestination, KeySub, KeyClient, KeyIP:
+		default:
+			return nil, fmt.Errorf("invalid rate limit rule %q: unknown key %q", raw, key)
+		}
+
+		limitStr, windowStr, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit rule %q: expected limit/window", raw)
+		}
+		limit, err := strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid rate limit rule %q: invalid limit", raw)
+		}
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid rate limit rule %q: invalid window", raw)
+		}
+
+		rules = append(rules, Rule{Trigger: trigger, Key: key, Limit: limit, Window: window})
+	}
+	return rules, nil
+}
+
+// Request identifies the sender of one trigger invocation. Empty key values
+// are not counted.
+type Request struct {
+	Trigger string
+	Keys    map[string]string
+}
+
+// Result is passed to the policy as input.rateLimit.
+type Result struct {
+	Exceeded bool     `json:"exceeded"`
+	Limits   []Status `json:"limits"`
+}
+
+// Status is the state of one rule after counting the request.
+type Status struct {
+	Key      string  `json:"key"`
+	Limit    int64   `json:"limit"`
+	Window   string  `json:"window"`
+	Count    float64 `json:"count"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// Limiter applies rules to requests using sliding window counters: the count
+// is the current fixed window plus the previous window weighted by how much of
+// it still overlaps the sliding window.
+type Limiter struct {
+	Store Store
+	Rules []Rule
+	// Secret keys the HMAC of counter keys so a stored key cannot be
+	// brute-forced back to its address, phone number or IP.
+	Secret []byte
+	now    func() time.Time
+}
+
+// NewLimiter creates the limiter selected by configuration. It returns nil
+// when no rules are configured.
+func NewLimiter(cfg *config.Config) (*Limiter, error) {
+	rules, err := ParseRules(cfg.AppRateLimitRules)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	var store Store
+	switch cfg.AppRateLimitStore {
+	case "dynamodb":
+		store = NewDynamoDBStore(cfg)
+	case "memory", "":
+		store = NewMemoryStore()
+	default:
+		return nil, fmt.Errorf("unknown rate limit store: %s", cfg.AppRateLimitStore)
+	}
+
+	// counters of the memory store never leave the instance, so a random
+	// secret will do; a shared store needs the same secret on every instance
+	secret := []byte(cfg.AppRateLimitSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("error generating rate limit secret: %w", err)
+		}
+	}
+
+	return &Limiter{Store: store, Rules: rules, Secret: secret}, nil
+}
+
+// Check counts req against every matching rule and reports the resulting
+// window counts. On a store error the remaining rules are skipped.
+func (l *Limiter) Check(ctx context.Context, req Request) (*Result, error) {
+	now := time.Now()
+	if l.now != nil {
+		now = l.now()
+	}
+
+	res := &Result{Limits: []Status{}}
+	for _, rule := range l.Rules {
+		if rule.Trigger != "*" && rule.Trigger != req.Trigger {
+			continue
+		}
+		value := req.Keys[rule.Key]
+		if value == "" {
+			continue
+		}
+
+		count, err := l.hit(ctx, rule, value, now)
+		if err != nil {
+			return nil, fmt.Errorf("error counting %s rate limit: %w", rule.Key, err)
+		}
+
+		st := Status{
+			Key:      rule.Key,
+			Limit:    rule.Limit,
+			Window:   rule.Window.String(),
+			Count:    count,
+			Exceeded: count > float64(rule.Limit),
+		}
+		res.Exceeded = res.Exceeded || st.Exceeded
+		res.Limits = append(res.Limits, st)
+	}
+	return res, nil
+}
+
+func (l *Limiter) hit(ctx context.Context, rule Rule, value string, now time.Time) (float64, error) {
+	window := rule.Window.Nanoseconds()
+	bucket := now.UnixNano() / window
+	elapsed := float64(now.UnixNano()%window) / float64(window)
+
+	base := l.counterKey(rule, value)
+	current, err := l.Store.Increment(ctx, fmt.Sprintf("%s#%d", base, bucket), 2*rule.Window)
+	if err != nil {
+		return 0, err
+	}
+	previous, err := l.Store.Get(ctx, fmt.Sprintf("%s#%d", base, bucket-1))
+	if err != nil {
+		return 0, err
+	}
+
+	return float64(current) + float64(previous)*(1-elapsed), nil
+}
+
+// counterKey identifies the counters of one rule and key value. Values are
+// keyed with an HMAC so no addresses are stored.
+func (l *Limiter) counterKey(rule Rule, value string) string {
+	mac := hmac.New(sha256.New, l.Secret)
+	mac.Write([]byte(strings.ToLower(value)))
+	return fmt.Sprintf("%s#%s#%s#%d", rule.Trigger, rule.Key, hex.EncodeToString(mac.Sum(nil)), int64(rule.Window.Seconds()))
+}
@@ -0,0 +1,197 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules("CustomEmailSender_ForgotPassword:destination:5/1h, *:ip:100/10m,")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(rules))
+	}
+	if rules[0] != (Rule{Trigger: "CustomEmailSender_ForgotPassword", Key: KeyDestination, Limit: 5, Window: time.Hour}) {
+		t.Errorf("unexpected first rule: %+v", rules[0])
+	}
+	if rules[1].Trigger != "*" || rules[1].Key != KeyIP || rules[1].Window != 10*time.Minute {
+		t.Errorf("unexpected second rule: %+v", rules[1])
+	}
+
+	for _, invalid := range []string{
+		"destination:5/1h",
+		"*:email:5/1h",
+		"*:sub:5",
+		"*:sub:0/1h",
+		"*:sub:5/forever",
+	} {
+		if _, err := ParseRules(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestLimiter_SlidingWindow(t *testing.T) {
+	// start a quarter into a fixed window
+	now := time.Unix(0, 0).Add(100*time.Hour + 15*time.Minute)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	l := &Limiter{
+		Store: store,
+		Rules: []Rule{
+			{Trigger: "CustomEmailSender_ForgotPassword", Key: KeyDestination, Limit: 3, Window: time.Hour},
+			{Trigger: "CustomEmailSender_SignUp", Key: KeyDestination, Limit: 1, Window: time.Hour},
+		},
+		now: func() time.Time { return now },
+	}
+	req := Request{
+		Trigger: "CustomEmailSender_ForgotPassword",
+		Keys:    map[string]string{KeyDestination: "user@example.com"},
+	}
+
+	var res *Result
+	for i := 0; i < 4; i++ {
+		var err error
+		res, err = l.Check(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Limits) != 1 {
+			t.Fatalf("expected only the matching trigger rule, got %+v", res.Limits)
+		}
+		if want := i == 3; res.Exceeded != want {
+			t.Fatalf("request %d: expected exceeded=%v, got %+v", i+1, want, res)
+		}
+	}
+
+	// half way into the next window half of the previous 4 requests still count
+	now = now.Add(75 * time.Minute)
+	res, err := l.Check(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Limits[0].Count != 3 || res.Exceeded {
+		t.Errorf("expected weighted count 3 within the limit, got %+v", res.Limits[0])
+	}
+
+	// other addresses are counted separately, case-insensitively
+	req.Keys[KeyDestination] = "USER@example.com"
+	res, _ = l.Check(context.Background(), req)
+	if res.Limits[0].Count != 4 {
+		t.Errorf("expected addresses to be case-insensitive, got %+v", res.Limits[0])
+	}
+	req.Keys[KeyDestination] = "other@example.com"
+	res, _ = l.Check(context.Background(), req)
+	if res.Limits[0].Count != 1 {
+		t.Errorf("expected a separate counter per address, got %+v", res.Limits[0])
+	}
+}
+
+func TestLimiter_CounterKey(t *testing.T) {
+	rule := Rule{Trigger: "*", Key: KeyDestination, Limit: 1, Window: time.Hour}
+	l := &Limiter{Secret: []byte("secret-1")}
+	k := l.counterKey(rule, "User@example.com")
+	if k != l.counterKey(rule, "user@example.com") {
+		t.Error("expected key to be case-insensitive")
+	}
+	if strings.Contains(k, "example.com") {
+		t.Errorf("expected value to be hashed, got %s", k)
+	}
+	other := &Limiter{Secret: []byte("secret-2")}
+	if k == other.counterKey(rule, "user@example.com") {
+		t.Error("expected keys to depend on the secret")
+	}
+}
+
+func TestLimiter_SkipsEmptyKeys(t *testing.T) {
+	l := &Limiter{
+		Store: NewMemoryStore(),
+		Rules: []Rule{{Trigger: "*", Key: KeyIP, Limit: 1, Window: time.Minute}},
+	}
+
+	res, err := l.Check(context.Background(), Request{Trigger: "CustomSMSSender_SignUp", Keys: map[string]string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Limits) != 0 || res.Exceeded {
+		t.Errorf("expected no limits without an ip, got %+v", res)
+	}
+}
+
+// fakeDynamoDB implements ADD on the count attribute of in-memory items.
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]int64
+	err   error
+}
+
+func (f *fakeDynamoDB) UpdateItem(ctx context.Context, in *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !strings.Contains(*in.UpdateExpression, "ADD #count :one") || in.ExpressionAttributeValues[":expires_at"] == nil {
+		return nil, errors.New("unexpected update expression")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pk := in.Key["pk"].(*ddbtypes.AttributeValueMemberS).Value
+	f.items[pk]++
+	return &dynamodb.UpdateItemOutput{Attributes: map[string]ddbtypes.AttributeValue{
+		"count": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(f.items[pk], 10)},
+	}}, nil
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pk := in.Key["pk"].(*ddbtypes.AttributeValueMemberS).Value
+	v, ok := f.items[pk]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	return &dynamodb.GetItemOutput{Item: map[string]ddbtypes.AttributeValue{
+		"count": &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(v, 10)},
+	}}, nil
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	client := &fakeDynamoDB{items: map[string]int64{}}
+	s := &DynamoDBStore{Client: client, Table: "rate-limits"}
+	ctx := context.Background()
+
+	for want := int64(1); want <= 2; want++ {
+		got, err := s.Increment(ctx, "k", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("expected count %d, got %d", want, got)
+		}
+	}
+
+	if got, _ := s.Get(ctx, "k"); got != 2 {
+		t.Errorf("expected stored count 2, got %d", got)
+	}
+	if got, _ := s.Get(ctx, "missing"); got != 0 {
+		t.Errorf("expected zero for missing counter, got %d", got)
+	}
+
+	client.err = errors.New("throttled")
+	if _, err := s.Increment(ctx, "k", time.Hour); err == nil {
+		t.Error("expected error from client")
+	}
+}
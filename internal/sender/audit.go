@@ -0,0 +1,20 @@
+package sender
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/audit"
+)
+
+// startAudit starts the audit record of an invocation and attaches it to ctx
+// so provider attempts are added to it. The record is nil when auditing is
+// disabled.
+func (s *Sender) startAudit(ctx context.Context, channel string, header events.CognitoEventUserPoolsHeader, userAttributes map[string]any) (context.Context, *audit.Record) {
+	sub, _ := userAttributes["sub"].(string)
+	rec := s.Auditor.NewRecord(channel, header.UserPoolID, sub, header.TriggerSource, header.CallerContext.ClientID)
+	if rec == nil {
+		return ctx, nil
+	}
+	return audit.NewContext(ctx, rec), rec
+}
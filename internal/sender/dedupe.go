@@ -0,0 +1,38 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/dedupe"
+)
+
+// acquireSend claims the idempotency key of a send and reports whether the
+// send should go ahead. Store errors are logged and the send is not blocked.
+func (s *Sender) acquireSend(ctx context.Context, key string) bool {
+	if s.Deduper == nil {
+		return true
+	}
+
+	err := s.Deduper.Acquire(ctx, key)
+	if errors.Is(err, dedupe.ErrDuplicate) {
+		slog.InfoContext(ctx, "duplicate send suppressed", "idempotency_key", key)
+		return false
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "dedupe check failed, sending anyway", "error", err)
+	}
+	return true
+}
+
+// releaseSend frees the idempotency key of a failed send so the retry of the
+// invocation is delivered.
+func (s *Sender) releaseSend(ctx context.Context, key string) {
+	if s.Deduper == nil {
+		return
+	}
+	if err := s.Deduper.Release(ctx, key); err != nil {
+		slog.WarnContext(ctx, "failed to release idempotency key", "idempotency_key", key, "error", err)
+	}
+}
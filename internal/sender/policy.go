@@ -0,0 +1,128 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/aws"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/opa"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/verifier"
+)
+
+// EmailPolicyInput builds the email policy input for event.
+func EmailPolicyInput(event aws.CognitoEventUserPoolsCustomEmailSender, verification *verifier.EmailVerificationResult) PolicyInput {
+	return PolicyInput{
+		Trigger:           event.TriggerSource,
+		CallerContext:     event.CallerContext,
+		UserAttributes:    event.Request.UserAttributes,
+		ClientMetadata:    event.Request.ClientMetadata,
+		EmailVerification: verification,
+	}
+}
+
+// SMSPolicyInput builds the sms policy input for event.
+func SMSPolicyInput(event aws.CognitoEventUserPoolsCustomSMSSender) PolicyInput {
+	return PolicyInput{
+		Trigger:        event.TriggerSource,
+		CallerContext:  event.CallerContext,
+		UserAttributes: event.Request.UserAttributes,
+		ClientMetadata: event.Request.ClientMetadata,
+	}
+}
+
+// LoadEmailPolicy prepares the configured email policy without creating any
+// providers, e.g. to test it offline.
+func LoadEmailPolicy(ctx context.Context, cfg *config.Config) (*opa.PreparedPolicy, error) {
+	pp, _, err := loadPolicy(ctx, cfg, cfg.AppEmailSenderPolicyPath, emailPolicyQuery)
+	return pp, err
+}
+
+// LoadSMSPolicy prepares the configured sms policy, or returns nil if sms is
+// not configured.
+func LoadSMSPolicy(ctx context.Context, cfg *config.Config) (*opa.PreparedPolicy, error) {
+	if cfg.AppSMSSenderPolicyPath == "" {
+		return nil, nil
+	}
+	pp, _, err := loadPolicy(ctx, cfg, cfg.AppSMSSenderPolicyPath, smsPolicyQuery)
+	return pp, err
+}
+
+// loadPolicy prepares the policy at location. Local .rego files and policy
+// directories are read once; s3:// and http(s):// locations are loaded as OPA
+// bundles and returned with a reloader that refreshes them by ETag.
+func loadPolicy(ctx context.Context, cfg *config.Config, location, query string) (*opa.PreparedPolicy, *opa.Reloader, error) {
+	source, err := newBundleSource(cfg, location)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if source == nil {
+		if info, err := os.Stat(location); err == nil && info.IsDir() {
+			pp, err := opa.PreparePolicyDir(ctx, location, query)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to prepare policy directory %s: %w", location, err)
+			}
+			return pp, nil, nil
+		}
+
+		policy, err := opa.ReadPolicy(location)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read policy at path %s: %w", location, err)
+		}
+		pp, err := opa.PreparePolicy(ctx, policy, query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to prepare policy: %w", err)
+		}
+		return pp, nil, nil
+	}
+
+	pp, reloader, err := opa.LoadBundle(ctx, source, query, cfg.AppPolicyRefreshInterval)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load policy bundle at %s: %w", location, err)
+	}
+	return pp, reloader, nil
+}
+
+// newBundleSource returns the bundle source for location, or nil if location
+// is a local file path.
+func newBundleSource(cfg *config.Config, location string) (opa.BundleSource, error) {
+	switch {
+	case strings.HasPrefix(location, "s3://"):
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy location %s: %w", location, err)
+		}
+		key := strings.TrimPrefix(u.Path, "/")
+		if u.Host == "" || key == "" {
+			return nil, fmt.Errorf("invalid policy location %s: expected s3://bucket/key", location)
+		}
+		return &opa.S3BundleSource{
+			Client: s3.NewFromConfig(*cfg.AWSConfig),
+			Bucket: u.Host,
+			Key:    key,
+		}, nil
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return &opa.HTTPBundleSource{
+			Client: &http.Client{Timeout: 10 * time.Second},
+			URL:    location,
+			Token:  cfg.AppPolicyBundleToken,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// refreshPolicy checks a bundle policy for updates; it is a no-op for local
+// policy files.
+func refreshPolicy(ctx context.Context, r *opa.Reloader) {
+	if r != nil {
+		r.MaybeRefresh(ctx)
+	}
+}
@@ -0,0 +1,39 @@
+package sender
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/ratelimit"
+)
+
+// checkRateLimit counts the request described by input and returns the result
+// for the policy. destinationAttr is the user attribute holding the recipient.
+// Store errors are logged and the request is not limited.
+func (s *Sender) checkRateLimit(ctx context.Context, input PolicyInput, destinationAttr string) *ratelimit.Result {
+	if s.RateLimiter == nil {
+		return nil
+	}
+
+	destination, _ := input.UserAttributes[destinationAttr].(string)
+	sub, _ := input.UserAttributes["sub"].(string)
+
+	res, err := s.RateLimiter.Check(ctx, ratelimit.Request{
+		Trigger: input.Trigger,
+		Keys: map[string]string{
+			ratelimit.KeyDestination: destination,
+			ratelimit.KeySub:         sub,
+			ratelimit.KeyClient:      input.CallerContext.ClientID,
+			ratelimit.KeyIP:          input.ClientMetadata[s.Config.AppRateLimitIPMetadataKey],
+		},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit check failed, not limiting", "error", err)
+		return nil
+	}
+
+	if res.Exceeded {
+		slog.InfoContext(ctx, "rate limit exceeded", "trigger", input.Trigger, "limits", res.Limits)
+	}
+	return res
+}
@@ -2,17 +2,36 @@ package sender
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/audit"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/aws"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/deadletter"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/decisionlog"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/dedupe"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/encryption"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/metrics"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/opa"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/providers"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/ratelimit"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/verifier"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	emailPolicyQuery = "data.cognito_custom_sender_email_policy.result"
+	smsPolicyQuery   = "data.cognito_custom_sender_sms_policy.result"
 )
 
 type Sender struct {
@@ -21,6 +40,29 @@ type Sender struct {
 	EmailVerifier  verifier.EmailVerifier
 	PreparedPolicy *opa.PreparedPolicy
 	Provider       providers.Provider
+
+	// PolicyReloader is set when the policy is loaded from a bundle
+	PolicyReloader *opa.Reloader
+
+	// RateLimiter is set when rate limit rules are configured
+	RateLimiter *ratelimit.Limiter
+
+	// Deduper is set when deduplication is enabled
+	Deduper *dedupe.Deduper
+
+	// Suppression is set when suppression sources are configured
+	Suppression *suppression.Checker
+
+	// Metrics is set when metrics are enabled
+	Metrics *metrics.Emitter
+
+	// Auditor is set when an audit sink is configured
+	Auditor *audit.Auditor
+
+	// SMSPolicy and SMSProvider are only set when sms sending is configured
+	SMSPolicy         *opa.PreparedPolicy
+	SMSPolicyReloader *opa.Reloader
+	SMSProvider       providers.SMSProvider
 }
 
 func NewSender(ctx context.Context, cfg *config.Config) (*Sender, error) {
@@ -31,15 +73,35 @@ func NewSender(ctx context.Context, cfg *config.Config) (*Sender, error) {
 	if cfg.AppEmailSenderPolicyPath == "" {
 		return nil, fmt.Errorf("policy path is empty")
 	}
-	policyQuery := "data.cognito_custom_sender_email_policy.result"
-	policy, err := opa.ReadPolicy(cfg.AppEmailSenderPolicyPath)
+	preparedPolicy, reloader, err := loadPolicy(ctx, cfg, cfg.AppEmailSenderPolicyPath, emailPolicyQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	decisionLogger, err := decisionlog.NewLogger(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("decision logger init error: %w", err)
+	}
+	preparedPolicy.SetDecisionLogger(decisionLogger)
+
+	rateLimiter, err := ratelimit.NewLimiter(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("rate limiter init error: %w", err)
+	}
+
+	deduper, err := dedupe.NewDeduper(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("deduper init error: %w", err)
+	}
+
+	suppressionChecker, err := suppression.NewChecker(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read policy at path %s: %w", cfg.AppEmailSenderPolicyPath, err)
+		return nil, fmt.Errorf("suppression checker init error: %w", err)
 	}
 
-	preparedPolicy, err := opa.PreparePolicy(ctx, policy, policyQuery)
+	auditor, err := audit.NewAuditor(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare policy: %w", err)
+		return nil, fmt.Errorf("auditor init error: %w", err)
 	}
 
 	emailVerifier, err := NewEmailVerifier(cfg)
@@ -52,39 +114,138 @@ func NewSender(ctx context.Context, cfg *config.Config) (*Sender, error) {
 		return nil, fmt.Errorf("failed to create email provider: %w", err)
 	}
 
-	return &Sender{
+	s := &Sender{
 		Config:         cfg,
 		KMS:            aws.KMS,
 		Provider:       p,
 		PreparedPolicy: preparedPolicy,
+		PolicyReloader: reloader,
+		RateLimiter:    rateLimiter,
+		Deduper:        deduper,
+		Suppression:    suppressionChecker,
+		Metrics:        metrics.NewEmitter(cfg),
+		Auditor:        auditor,
 		EmailVerifier:  emailVerifier,
-	}, nil
+	}
+
+	if cfg.AppSMSSenderPolicyPath != "" {
+		s.SMSPolicy, s.SMSPolicyReloader, err = loadPolicy(ctx, cfg, cfg.AppSMSSenderPolicyPath, smsPolicyQuery)
+		if err != nil {
+			return nil, fmt.Errorf("sms policy: %w", err)
+		}
+		s.SMSPolicy.SetDecisionLogger(decisionLogger)
+
+		s.SMSProvider, err = providers.NewSMSProvider(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create sms provider: %w", err)
+		}
+	}
+
+	return s, nil
 }
 
-func (s *Sender) SendEmail(ctx context.Context, event aws.CognitoEventUserPoolsCustomEmailSender) error {
+// HandleEvent decodes a raw Cognito custom sender event and routes it to the
+// email or sms path based on its trigger source.
+func (s *Sender) HandleEvent(ctx context.Context, raw json.RawMessage) (err error) {
+	var header events.CognitoEventUserPoolsHeader
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return fmt.Errorf("failed to parse event header: %w", err)
+	}
+
+	ctx, span := tracing.StartInvocation(ctx, "HandleEvent",
+		attribute.String("cognito.trigger", header.TriggerSource),
+		attribute.String("cognito.user_pool_id", header.UserPoolID),
+		attribute.String("cognito.client_id", header.CallerContext.ClientID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	scope := s.Metrics.NewScope(header.UserPoolID, header.CallerContext.ClientID, header.TriggerSource)
+	ctx = metrics.NewContext(ctx, scope)
+	defer func() {
+		if err := scope.Flush(); err != nil {
+			slog.WarnContext(ctx, "failed to flush metrics", "error", err)
+		}
+	}()
+
+	if IsSMSTrigger(header.TriggerSource) {
+		var event aws.CognitoEventUserPoolsCustomSMSSender
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return fmt.Errorf("failed to parse sms event: %w", err)
+		}
+		return s.SendSMS(ctx, event)
+	}
+
+	var event aws.CognitoEventUserPoolsCustomEmailSender
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return fmt.Errorf("failed to parse email event: %w", err)
+	}
+	return s.SendEmail(ctx, event)
+}
+
+// IsSMSTrigger reports whether the trigger source belongs to the Cognito
+// Custom SMS Sender trigger.
+func IsSMSTrigger(trigger string) bool {
+	return strings.HasPrefix(trigger, "CustomSMSSender_")
+}
+
+func (s *Sender) SendEmail(ctx context.Context, event aws.CognitoEventUserPoolsCustomEmailSender) (err error) {
+	ctx, rec := s.startAudit(ctx, "email", event.CognitoEventUserPoolsHeader, event.Request.UserAttributes)
+	defer func() { s.Auditor.Write(ctx, rec, err) }()
+
 	data, err := s.GetEmailData(ctx, event)
 	if err != nil {
 		return fmt.Errorf("failed to get email data: %w", err)
 	}
 
 	if data == nil {
+		rec.SetOutcome(audit.OutcomeDenied)
 		return nil // do nothing
 	}
 
-	code, err := encryption.Decrypt(ctx, s.Config.AppKmsKeyId, event.Request.Code)
+	code, err := s.decryptCode(ctx, event.Request.Code)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt verification code: %w", err)
+		return err
 	}
 	data.VerificationCode = code
 
-	err = s.Provider.Send(ctx, data)
+	// keep the encrypted event so undelivered emails can be replayed
+	if raw, err := json.Marshal(event); err == nil {
+		ctx = deadletter.ContextWithEvent(ctx, raw)
+	}
+
+	sub, _ := event.Request.UserAttributes["sub"].(string)
+	key := s.Deduper.Key(event.UserPoolID, sub, event.TriggerSource, code)
+	if !s.acquireSend(ctx, key) {
+		rec.SetOutcome(audit.OutcomeDuplicate)
+		return nil
+	}
+
+	receipt, err := s.Provider.Send(ctx, data)
 	if err != nil {
+		s.releaseSend(ctx, key)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
+	recordReceipt(ctx, receipt)
 
 	return nil
 }
 
+// recordReceipt logs the provider message ID of a sent email and adds it to
+// the invocation's metrics and audit record. The receipt is nil when the
+// failover chain swallowed an undelivered email.
+func recordReceipt(ctx context.Context, receipt *providers.Receipt) {
+	if receipt == nil {
+		return
+	}
+	slog.InfoContext(ctx, "email sent",
+		"provider", receipt.Provider,
+		"message_id", receipt.MessageID,
+		"attempts", receipt.Attempts,
+	)
+	metrics.Property(ctx, "MessageId", receipt.MessageID)
+	audit.FromContext(ctx).SetDelivery(receipt.Provider, receipt.MessageID)
+}
+
 // GetEmailData retrieves the email data based on a policy evaluation.
 func (s *Sender) GetEmailData(ctx context.Context, event aws.CognitoEventUserPoolsCustomEmailSender) (*types.EmailData, error) {
 	var verificationData *verifier.EmailVerificationResult
@@ -96,20 +257,31 @@ func (s *Sender) GetEmailData(ctx context.Context, event aws.CognitoEventUserPoo
 			return nil, errors.New("missing or invalid 'email' in user attributes")
 		}
 
-		verificationData, err = s.EmailVerifier.VerifyEmail(ctx, email)
+		verifyCtx, span := tracing.Start(ctx, "EmailVerifier.VerifyEmail")
+		verificationData, err = s.EmailVerifier.VerifyEmail(verifyCtx, email)
+		tracing.End(span, err)
+		outcome := verificationOutcome(verificationData, err)
+		metrics.Count(ctx, "Verifications", metrics.Dim("Outcome", outcome))
+		audit.FromContext(ctx).SetVerification(outcome)
+
 		if err != nil {
-			slog.WarnContext(ctx, "email verification failed", "email", email, "error", err)
+			switch s.Config.AppEmailVerificationFailureMode {
+			case "closed":
+				return nil, fmt.Errorf("email verification error: %w", err)
+			case "open":
+				slog.WarnContext(ctx, "email verification failed, passing unknown result to policy", "email", email, "error", err)
+				verificationData = verifier.UnknownResult(err)
+			default:
+				slog.WarnContext(ctx, "email verification failed", "email", email, "error", err)
+			}
 		}
 	}
 
-	policyInput := PolicyInput{
-		Trigger:           event.TriggerSource,
-		CallerContext:     event.CallerContext,
-		UserAttributes:    event.Request.UserAttributes,
-		ClientMetadata:    event.Request.ClientMetadata,
-		EmailVerification: verificationData,
-	}
+	policyInput := EmailPolicyInput(event, verificationData)
+	policyInput.RateLimit = s.checkRateLimit(ctx, policyInput, "email")
+	policyInput.Suppression = s.checkSuppression(ctx, policyInput)
 
+	refreshPolicy(ctx, s.PolicyReloader)
 	output, err := opa.Evaluate[PolicyOutput](ctx, s.PreparedPolicy, policyInput)
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate policy: %w", err)
@@ -118,10 +290,12 @@ func (s *Sender) GetEmailData(ctx context.Context, event aws.CognitoEventUserPoo
 	if output.Action == "" {
 		return nil, errors.New("desired action missing")
 	}
+	audit.FromContext(ctx).SetDecision(output.Action, output.Reason)
 
 	if output.Action != "allow" {
 		email, _ := event.Request.UserAttributes["email"].(string)
 		slog.InfoContext(ctx, "send request denied by policy", "email", email, "reason", output.Reason)
+		metrics.Count(ctx, "PolicyDenials", metrics.Dim("Channel", "email"), metrics.Dim("Reason", output.Reason))
 		return nil, nil
 	}
 
@@ -134,6 +308,11 @@ func (s *Sender) GetEmailData(ctx context.Context, event aws.CognitoEventUserPoo
 }
 
 func (s *Sender) ParseEmailData(data *types.EmailData) (*types.EmailData, error) {
+	// the first additional destination stands in for a missing primary one
+	if data.DestinationAddress == "" && len(data.DestinationAddresses) > 0 {
+		data.DestinationAddress = data.DestinationAddresses[0]
+		data.DestinationAddresses = data.DestinationAddresses[1:]
+	}
 	if data.DestinationAddress == "" {
 		return nil, errors.New("destination address missing or invalid")
 	}
@@ -155,21 +334,170 @@ func (s *Sender) ParseEmailData(data *types.EmailData) (*types.EmailData, error)
 		}
 	}
 
-	if s.Config.AppEmailProvider == "sendgrid" && data.Providers.SendGrid == nil {
+	// the first provider of a policy-chosen order replaces the configured one
+	primary := s.Config.AppEmailProvider
+	if len(data.ProviderOrder) > 0 {
+		configured := providers.ConfiguredProviders(s.Config)
+		for _, name := range data.ProviderOrder {
+			if name != "ses" && name != "sendgrid" && name != "smtp" {
+				return nil, fmt.Errorf("unknown provider in provider order: %s", name)
+			}
+			if !slices.Contains(configured, name) {
+				return nil, fmt.Errorf("provider in provider order is not configured: %s", name)
+			}
+		}
+		primary = data.ProviderOrder[0]
+	}
+
+	if primary == "sendgrid" && data.Providers.SendGrid == nil {
 		return nil, fmt.Errorf("email provider is sendgrid but email data does not include data for sendgrid provider")
 	}
 
+	if primary == "smtp" && data.Providers.SMTP == nil {
+		return nil, fmt.Errorf("email provider is smtp but email data does not include data for smtp provider")
+	}
+
 	return data, nil
 }
 
+func (s *Sender) SendSMS(ctx context.Context, event aws.CognitoEventUserPoolsCustomSMSSender) (err error) {
+	ctx, rec := s.startAudit(ctx, "sms", event.CognitoEventUserPoolsHeader, event.Request.UserAttributes)
+	defer func() { s.Auditor.Write(ctx, rec, err) }()
+
+	if s.SMSPolicy == nil || s.SMSProvider == nil {
+		return errors.New("sms sending is not configured")
+	}
+
+	data, err := s.GetSMSData(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to get sms data: %w", err)
+	}
+
+	if data == nil {
+		rec.SetOutcome(audit.OutcomeDenied)
+		return nil // do nothing
+	}
+
+	code, err := s.decryptCode(ctx, event.Request.Code)
+	if err != nil {
+		return err
+	}
+	data.VerificationCode = code
+
+	// keep the encrypted event so undelivered sms messages can be replayed
+	if raw, err := json.Marshal(event); err == nil {
+		ctx = deadletter.ContextWithEvent(ctx, raw)
+	}
+
+	sub, _ := event.Request.UserAttributes["sub"].(string)
+	key := s.Deduper.Key(event.UserPoolID, sub, event.TriggerSource, code)
+	if !s.acquireSend(ctx, key) {
+		rec.SetOutcome(audit.OutcomeDuplicate)
+		return nil
+	}
+
+	err = s.SMSProvider.SendSMS(ctx, data)
+	if err != nil {
+		s.releaseSend(ctx, key)
+		return fmt.Errorf("failed to send sms: %w", err)
+	}
+
+	return nil
+}
+
+// GetSMSData retrieves the sms data based on a policy evaluation.
+func (s *Sender) GetSMSData(ctx context.Context, event aws.CognitoEventUserPoolsCustomSMSSender) (*types.SMSData, error) {
+	policyInput := SMSPolicyInput(event)
+	policyInput.RateLimit = s.checkRateLimit(ctx, policyInput, "phone_number")
+
+	refreshPolicy(ctx, s.SMSPolicyReloader)
+	output, err := opa.Evaluate[SMSPolicyOutput](ctx, s.SMSPolicy, policyInput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate sms policy: %w", err)
+	}
+
+	if output.Action == "" {
+		return nil, errors.New("desired action missing")
+	}
+	audit.FromContext(ctx).SetDecision(output.Action, output.Reason)
+
+	if output.Action != "allow" {
+		phone, _ := event.Request.UserAttributes["phone_number"].(string)
+		slog.InfoContext(ctx, "sms send request denied by policy", "phone_number", phone, "reason", output.Reason)
+		metrics.Count(ctx, "PolicyDenials", metrics.Dim("Channel", "sms"), metrics.Dim("Reason", output.Reason))
+		return nil, nil
+	}
+
+	return s.ParseSMSData(&output.Allow)
+}
+
+func (s *Sender) ParseSMSData(data *types.SMSData) (*types.SMSData, error) {
+	if data.DestinationPhoneNumber == "" {
+		return nil, errors.New("destination phone number missing or invalid")
+	}
+	if data.Message == "" {
+		return nil, errors.New("message missing or invalid")
+	}
+	if !strings.Contains(data.Message, providers.SMSCodePlaceholder) {
+		return nil, fmt.Errorf("message does not contain the %s code placeholder", providers.SMSCodePlaceholder)
+	}
+
+	return data, nil
+}
+
+// decryptCode decrypts the verification code of an event and records how long
+// KMS took.
+func (s *Sender) decryptCode(ctx context.Context, encrypted string) (string, error) {
+	start := time.Now()
+	code, err := encryption.Decrypt(ctx, s.Config.AppKmsKeyId, encrypted)
+	metrics.Duration(ctx, "KMSDecryptLatency", time.Since(start))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt verification code: %w", err)
+	}
+	return code, nil
+}
+
+// verificationOutcome names the result of an email verification for metrics.
+func verificationOutcome(res *verifier.EmailVerificationResult, err error) string {
+	switch {
+	case err != nil || res == nil:
+		return "error"
+	case res.IsValid:
+		return "valid"
+	default:
+		return "invalid"
+	}
+}
+
+// NewEmailVerifier creates the verifier selected by configuration. A
+// comma-separated list of providers creates a chain run in the given order.
 func NewEmailVerifier(cfg *config.Config) (verifier.EmailVerifier, error) {
-	switch cfg.AppEmailVerificationProvider {
+	names := cfg.EmailVerificationProviders()
+	if len(names) <= 1 {
+		return newEmailVerifier(cfg, cfg.AppEmailVerificationProvider)
+	}
+
+	steps := make([]verifier.ChainStep, 0, len(names))
+	for _, name := range names {
+		v, err := newEmailVerifier(cfg, name)
+		if err != nil {
+			return nil, fmt.Errorf("%s verifier init error: %w", name, err)
+		}
+		steps = append(steps, verifier.ChainStep{Name: name, Verifier: v})
+	}
+	return verifier.NewChainVerifier(steps...), nil
+}
+
+func newEmailVerifier(cfg *config.Config, name string) (verifier.EmailVerifier, error) {
+	switch strings.TrimSpace(name) {
 	case "sendgrid":
 		return verifier.NewSendGridVerifier(cfg)
+	case "dns":
+		return verifier.NewDNSVerifier(cfg), nil
 	case "offline", "":
 		return verifier.NewOfflineVerifier(), nil
 	default:
-		slog.Warn("unknown email verification provider, defaulting to offline", "provider", cfg.AppEmailVerificationProvider)
+		slog.Warn("unknown email verification provider, defaulting to offline", "provider", name)
 		return verifier.NewOfflineVerifier(), nil
 	}
 }
@@ -0,0 +1,31 @@
+package sender
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
+)
+
+// checkSuppression looks up the user's email address on the configured
+// suppression lists and returns the result for the policy. Sources that fail
+// are logged and left out of the result.
+func (s *Sender) checkSuppression(ctx context.Context, input PolicyInput) *suppression.Result {
+	if s.Suppression == nil {
+		return nil
+	}
+
+	email, _ := input.UserAttributes["email"].(string)
+	if email == "" {
+		return nil
+	}
+
+	res, err := s.Suppression.Check(ctx, email)
+	if err != nil {
+		slog.WarnContext(ctx, "suppression check failed", "error", err)
+	}
+	if res != nil && res.Suppressed {
+		slog.InfoContext(ctx, "email address is suppressed", "email", email, "entries", len(res.Entries))
+	}
+	return res
+}
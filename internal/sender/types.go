@@ -2,6 +2,8 @@ package sender
 
 import (
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/ratelimit"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/suppression"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/verifier"
 )
@@ -12,6 +14,8 @@ type PolicyInput struct {
 	UserAttributes    map[string]any                            `json:"userAttributes"`
 	ClientMetadata    map[string]string                         `json:"clientMetadata"`
 	EmailVerification *verifier.EmailVerificationResult         `json:"emailVerification,omitempty"`
+	RateLimit         *ratelimit.Result                         `json:"rateLimit,omitempty"`
+	Suppression       *suppression.Result                       `json:"suppression,omitempty"`
 }
 
 type PolicyOutput struct {
@@ -19,3 +23,9 @@ type PolicyOutput struct {
 	Reason string          `json:"reason,omitempty"`
 	Allow  types.EmailData `json:"allow,omitempty"`
 }
+
+type SMSPolicyOutput struct {
+	Action string        `json:"action"`
+	Reason string        `json:"reason,omitempty"`
+	Allow  types.SMSData `json:"allow,omitempty"`
+}
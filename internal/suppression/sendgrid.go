@@ -0,0 +1,79 @@
+package suppression
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/sendgrid/sendgrid-go"
+)
+
+// sendGridLists maps SendGrid suppression lists to entry reasons.
+var sendGridLists = []struct {
+	path   string
+	reason string
+}{
+	{"/v3/suppression/bounces/", ReasonBounce},
+	{"/v3/suppression/blocks/", ReasonBlock},
+	{"/v3/suppression/spam_reports/", ReasonComplaint},
+}
+
+type sendGridSuppression struct {
+	Created int64  `json:"created"`
+	Reason  string `json:"reason"`
+	Status  string `json:"status"`
+}
+
+// SendGridSource looks up addresses in the SendGrid bounce, block and spam
+// report lists.
+type SendGridSource struct {
+	APIHost string
+	APIKey  string
+}
+
+func NewSendGridSource(cfg *config.Config) *SendGridSource {
+	return &SendGridSource{
+		APIHost: cfg.SendGridApiHost,
+		APIKey:  cfg.SendGridEmailSendApiKey,
+	}
+}
+
+func (s *SendGridSource) Name() string {
+	return "sendgrid"
+}
+
+func (s *SendGridSource) Lookup(ctx context.Context, address string) ([]Entry, error) {
+	var entries []Entry
+	for _, list := range sendGridLists {
+		request := sendgrid.GetRequest(s.APIKey, list.path+url.PathEscape(address), s.APIHost)
+		request.Method = "GET"
+
+		response, err := sendgrid.MakeRequestWithContext(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("sendgrid api error: %w", err)
+		}
+		if response.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return nil, fmt.Errorf("sendgrid api returned status %d: %s", response.StatusCode, response.Body)
+		}
+
+		var found []sendGridSuppression
+		if err := json.Unmarshal([]byte(response.Body), &found); err != nil {
+			return nil, fmt.Errorf("sendgrid unmarshal error: %w", err)
+		}
+		for _, f := range found {
+			e := Entry{Source: "sendgrid", Reason: list.reason, Detail: f.Reason}
+			if f.Created > 0 {
+				e.CreatedAt = time.Unix(f.Created, 0).UTC()
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,65 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	awstypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// SESAPI is the subset of the SESv2 client used by SESSource.
+type SESAPI interface {
+	GetSuppressedDestination(ctx context.Context, params *sesv2.GetSuppressedDestinationInput, optFns ...func(*sesv2.Options)) (*sesv2.GetSuppressedDestinationOutput, error)
+}
+
+// SESSource looks up addresses in the SES account-level suppression list.
+type SESSource struct {
+	Client SESAPI
+}
+
+func NewSESSource(cfg *config.Config) *SESSource {
+	return &SESSource{Client: sesv2.NewFromConfig(*cfg.AWSConfig)}
+}
+
+func (s *SESSource) Name() string {
+	return "ses"
+}
+
+func (s *SESSource) Lookup(ctx context.Context, address string) ([]Entry, error) {
+	out, err := s.Client.GetSuppressedDestination(ctx, &sesv2.GetSuppressedDestinationInput{
+		EmailAddress: awssdk.String(address),
+	})
+	if err != nil {
+		var notFound *awstypes.NotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting suppressed destination: %w", err)
+	}
+
+	d := out.SuppressedDestination
+	if d == nil {
+		return nil, nil
+	}
+
+	e := Entry{Source: "ses", Reason: reasonFromSES(d.Reason)}
+	if d.LastUpdateTime != nil {
+		e.CreatedAt = *d.LastUpdateTime
+	}
+	return []Entry{e}, nil
+}
+
+func reasonFromSES(r awstypes.SuppressionListReason) string {
+	switch r {
+	case awstypes.SuppressionListReasonBounce:
+		return ReasonBounce
+	case awstypes.SuppressionListReasonComplaint:
+		return ReasonComplaint
+	default:
+		return string(r)
+	}
+}
@@ -0,0 +1,96 @@
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by DynamoDBStore.
+type DynamoDBAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+}
+
+// DynamoDBStore is a suppression list of our own, filled from provider
+// feedback events. Items are keyed by the lowercased address in the string
+// partition key "pk"; a newer event for an address replaces the older one.
+type DynamoDBStore struct {
+	Client DynamoDBAPI
+	Table  string
+}
+
+func NewDynamoDBStore(cfg *config.Config) *DynamoDBStore {
+	return &DynamoDBStore{
+		Client: dynamodb.NewFromConfig(*cfg.AWSConfig),
+		Table:  cfg.AppSuppressionTable,
+	}
+}
+
+func (s *DynamoDBStore) Name() string {
+	return "store"
+}
+
+func (s *DynamoDBStore) Lookup(ctx context.Context, address string) ([]Entry, error) {
+	out, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: awssdk.String(s.Table),
+		Key:       map[string]ddbtypes.AttributeValue{"pk": &ddbtypes.AttributeValueMemberS{Value: storeKey(address)}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading suppression entry: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	e := Entry{
+		Source: "store",
+		Reason: stringAttribute(out.Item, "reason"),
+		Detail: stringAttribute(out.Item, "detail"),
+	}
+	if created, err := time.Parse(time.RFC3339, stringAttribute(out.Item, "created_at")); err == nil {
+		e.CreatedAt = created
+	}
+	return []Entry{e}, nil
+}
+
+// Add records e as the suppression entry of address.
+func (s *DynamoDBStore) Add(ctx context.Context, address string, e Entry) error {
+	item := map[string]ddbtypes.AttributeValue{
+		"pk":     &ddbtypes.AttributeValueMemberS{Value: storeKey(address)},
+		"reason": &ddbtypes.AttributeValueMemberS{Value: e.Reason},
+	}
+	if e.Detail != "" {
+		item["detail"] = &ddbtypes.AttributeValueMemberS{Value: e.Detail}
+	}
+	if !e.CreatedAt.IsZero() {
+		item["created_at"] = &ddbtypes.AttributeValueMemberS{Value: e.CreatedAt.UTC().Format(time.RFC3339)}
+	}
+
+	_, err := s.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: awssdk.String(s.Table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("error writing suppression entry: %w", err)
+	}
+	return nil
+}
+
+func storeKey(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+func stringAttribute(item map[string]ddbtypes.AttributeValue, name string) string {
+	v, ok := item[name].(*ddbtypes.AttributeValueMemberS)
+	if !ok {
+		return ""
+	}
+	return v.Value
+}
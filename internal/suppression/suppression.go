@@ -0,0 +1,165 @@
+// Package suppression looks up recipients on provider suppression lists so
+// policies can avoid sending into known bounces and complaints.
+package suppression
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// Reasons an address is suppressed.
+const (
+	ReasonBounce    = "bounce"
+	ReasonBlock     = "block"
+	ReasonComplaint = "complaint"
+)
+
+// Source is a suppression list.
+type Source interface {
+	Name() string
+	// Lookup returns the entries for address, or none if it is not suppressed.
+	Lookup(ctx context.Context, address string) ([]Entry, error)
+}
+
+// Entry describes why an address is suppressed.
+type Entry struct {
+	Source    string    `json:"source"`
+	Reason    string    `json:"reason"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+}
+
+// Result is the suppression status of an address, passed to policies.
+type Result struct {
+	Suppressed bool    `json:"suppressed"`
+	Entries    []Entry `json:"entries,omitempty"`
+}
+
+// Checker looks up an address in every source and caches the result.
+type Checker struct {
+	Sources  []Source
+	CacheTTL time.Duration
+	// Timeout bounds each source's lookup so a slow list cannot use up the
+	// invocation before any provider is tried.
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedResult
+	now   func() time.Time
+}
+
+type cachedResult struct {
+	result    *Result
+	expiresAt time.Time
+}
+
+// NewChecker creates a Checker from configuration. It returns nil if no
+// sources are configured.
+func NewChecker(cfg *config.Config) (*Checker, error) {
+	if len(cfg.AppSuppressionSources) == 0 {
+		return nil, nil
+	}
+
+	var sources []Source
+	for _, name := range cfg.AppSuppressionSources {
+		switch name {
+		case "ses":
+			sources = append(sources, NewSESSource(cfg))
+		case "sendgrid":
+			sources = append(sources, NewSendGridSource(cfg))
+		case "store":
+			sources = append(sources, NewDynamoDBStore(cfg))
+		default:
+			return nil, fmt.Errorf("unknown suppression source: %s", name)
+		}
+	}
+
+	return &Checker{
+		Sources:  sources,
+		CacheTTL: cfg.AppSuppressionCacheTTL,
+		Timeout:  cfg.AppSuppressionTimeout,
+	}, nil
+}
+
+// Check looks up address in every source. Sources that fail or time out are
+// left out of the result and their errors are returned alongside it; such
+// partial results are not cached.
+func (c *Checker) Check(ctx context.Context, address string) (*Result, error) {
+	key := strings.ToLower(strings.TrimSpace(address))
+	if cached := c.cached(key); cached != nil {
+		return cached, nil
+	}
+
+	res := &Result{}
+	var errs []error
+	for _, s := range c.Sources {
+		entries, err := c.lookup(ctx, s, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.Name(), err))
+			continue
+		}
+		res.Entries = append(res.Entries, entries...)
+	}
+	res.Suppressed = len(res.Entries) > 0
+
+	if len(errs) > 0 {
+		return res, errors.Join(errs...)
+	}
+
+	c.store(key, res)
+	return res, nil
+}
+
+func (c *Checker) lookup(ctx context.Context, s Source, address string) ([]Entry, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	return s.Lookup(ctx, address)
+}
+
+func (c *Checker) cached(key string) *Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.cache[key]
+	if !ok || c.clock().After(e.expiresAt) {
+		return nil
+	}
+	return e.result
+}
+
+func (c *Checker) store(key string, res *Result) {
+	if c.CacheTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock()
+	if c.cache == nil {
+		c.cache = make(map[string]cachedResult)
+	}
+	// drop expired results so long-lived instances do not grow unbounded
+	for k, e := range c.cache {
+		if now.After(e.expiresAt) {
+			delete(c.cache, k)
+		}
+	}
+	c.cache[key] = cachedResult{result: res, expiresAt: now.Add(c.CacheTTL)}
+}
+
+func (c *Checker) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
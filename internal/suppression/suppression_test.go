@@ -0,0 +1,224 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	awstypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+type fakeSource struct {
+	entries map[string][]Entry
+	err     error
+	calls   int
+}
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) Lookup(ctx context.Context, address string) ([]Entry, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.entries[address], nil
+}
+
+func TestChecker_Check(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	src := &fakeSource{entries: map[string][]Entry{
+		"bounced@example.com": {{Source: "fake", Reason: ReasonBounce}},
+	}}
+	c := &Checker{Sources: []Source{src}, CacheTTL: time.Minute, now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	res, err := c.Check(ctx, "Bounced@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Suppressed || len(res.Entries) != 1 || res.Entries[0].Reason != ReasonBounce {
+		t.Errorf("expected bounce entry, got %+v", res)
+	}
+
+	res, _ = c.Check(ctx, "ok@example.com")
+	if res.Suppressed {
+		t.Errorf("expected address not to be suppressed, got %+v", res)
+	}
+
+	c.Check(ctx, "bounced@example.com")
+	if src.calls != 2 {
+		t.Errorf("expected cached result, got %d lookups", src.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	c.Check(ctx, "bounced@example.com")
+	if src.calls != 3 {
+		t.Errorf("expected lookup after cache expiry, got %d lookups", src.calls)
+	}
+}
+
+func TestChecker_SourceErrorNotCached(t *testing.T) {
+	failing := &fakeSource{err: errors.New("throttled")}
+	ok := &fakeSource{entries: map[string][]Entry{
+		"user@example.com": {{Source: "fake", Reason: ReasonComplaint}},
+	}}
+	c := &Checker{Sources: []Source{failing, ok}, CacheTTL: time.Minute}
+
+	res, err := c.Check(context.Background(), "user@example.com")
+	if err == nil {
+		t.Error("expected source error")
+	}
+	if res == nil || !res.Suppressed {
+		t.Errorf("expected partial result from working source, got %+v", res)
+	}
+
+	failing.err = nil
+	if _, err := c.Check(context.Background(), "user@example.com"); err != nil {
+		t.Errorf("expected lookup to be retried, got: %v", err)
+	}
+	if failing.calls != 2 {
+		t.Errorf("expected partial result not to be cached, got %d lookups", failing.calls)
+	}
+}
+
+// slowSource blocks until its lookup is cancelled.
+type slowSource struct{}
+
+func (slowSource) Name() string { return "slow" }
+
+func (slowSource) Lookup(ctx context.Context, address string) ([]Entry, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestChecker_SourceTimeout(t *testing.T) {
+	ok := &fakeSource{entries: map[string][]Entry{
+		"user@example.com": {{Source: "fake", Reason: ReasonBounce}},
+	}}
+	c := &Checker{Sources: []Source{slowSource{}, ok}, CacheTTL: time.Minute, Timeout: 10 * time.Millisecond}
+
+	res, err := c.Check(context.Background(), "user@example.com")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected lookup to time out, got: %v", err)
+	}
+	if res == nil || !res.Suppressed {
+		t.Errorf("expected partial result from working source, got %+v", res)
+	}
+
+	c.Check(context.Background(), "user@example.com")
+	if ok.calls != 2 {
+		t.Errorf("expected timed out result not to be cached, got %d lookups", ok.calls)
+	}
+}
+
+type fakeSES struct {
+	out *sesv2.GetSuppressedDestinationOutput
+	err error
+}
+
+func (f *fakeSES) GetSuppressedDestination(ctx context.Context, in *sesv2.GetSuppressedDestinationInput, optFns ...func(*sesv2.Options)) (*sesv2.GetSuppressedDestinationOutput, error) {
+	return f.out, f.err
+}
+
+func TestSESSource_Lookup(t *testing.T) {
+	updated := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := &SESSource{Client: &fakeSES{out: &sesv2.GetSuppressedDestinationOutput{
+		SuppressedDestination: &awstypes.SuppressedDestination{
+			Reason:         awstypes.SuppressionListReasonComplaint,
+			LastUpdateTime: &updated,
+		},
+	}}}
+
+	entries, err := s.Lookup(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Reason != ReasonComplaint || !entries[0].CreatedAt.Equal(updated) {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	s.Client = &fakeSES{err: &awstypes.NotFoundException{}}
+	entries, err = s.Lookup(context.Background(), "user@example.com")
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected not found to mean not suppressed, got %+v, %v", entries, err)
+	}
+
+	s.Client = &fakeSES{err: errors.New("access denied")}
+	if _, err := s.Lookup(context.Background(), "user@example.com"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestSendGridSource_Lookup(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected authorization header: %s", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v3/suppression/bounces/user@example.com":
+			w.Write([]byte(`[{"created":1700000000,"email":"user@example.com","reason":"550 5.1.1 user unknown","status":"5.1.1"}]`))
+		case "/v3/suppression/blocks/user@example.com":
+			w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"message":"not found"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := &SendGridSource{APIHost: srv.URL, APIKey: "key"}
+	entries, err := s.Lookup(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %+v", entries)
+	}
+	if entries[0].Reason != ReasonBounce || entries[0].Detail != "550 5.1.1 user unknown" || entries[0].CreatedAt.Unix() != 1700000000 {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+}
+
+type fakeDynamoDB struct {
+	items map[string]map[string]ddbtypes.AttributeValue
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, in *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	pk := in.Key["pk"].(*ddbtypes.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: f.items[pk]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, in *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.items[in.Item["pk"].(*ddbtypes.AttributeValueMemberS).Value] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBStore(t *testing.T) {
+	s := &DynamoDBStore{Client: &fakeDynamoDB{items: map[string]map[string]ddbtypes.AttributeValue{}}, Table: "suppression"}
+	ctx := context.Background()
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := s.Add(ctx, "Gone@Example.com", Entry{Reason: ReasonBounce, Detail: "550 user unknown", CreatedAt: created}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.Lookup(ctx, "gone@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Entry{Source: "store", Reason: ReasonBounce, Detail: "550 user unknown", CreatedAt: created}
+	if len(entries) != 1 || entries[0] != want {
+		t.Errorf("expected %+v, got %+v", want, entries)
+	}
+
+	entries, _ = s.Lookup(ctx, "ok@example.com")
+	if len(entries) != 0 {
+		t.Errorf("expected no entries, got %+v", entries)
+	}
+}
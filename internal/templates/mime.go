@@ -0,0 +1,136 @@
+package templates
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Header holds the addressing headers of a MIME message.
+type Header struct {
+	From    string
+	To      []string
+	Cc      []string
+	ReplyTo []string
+}
+
+// BuildMIMEMessage assembles an RFC 5322 message from a rendered email. The
+// body is multipart/alternative when both text and html parts are present.
+func BuildMIMEMessage(h Header, e *Email) ([]byte, error) {
+	var buf bytes.Buffer
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		name  string
+		addrs []string
+	}{
+		{"From", []string{h.From}},
+		{"To", h.To},
+		{"Cc", h.Cc},
+		{"Reply-To", h.ReplyTo},
+	} {
+		if len(field.addrs) == 0 {
+			continue
+		}
+		value, err := formatAddressList(field.addrs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", field.name, err)
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", field.name, value)
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", e.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().UTC().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), messageIDDomain(h.From))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if e.HTML == "" || e.Text == "" {
+		contentType := "text/plain"
+		body := e.Text
+		if e.HTML != "" {
+			contentType = "text/html"
+			body = e.HTML
+		}
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", e.Text},
+		{"text/html", e.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// formatAddressList formats addresses for a header. Display names are RFC 2047
+// encoded, and values containing line breaks are rejected so a policy-supplied
+// address cannot inject headers.
+func formatAddressList(addrs []string) (string, error) {
+	out := make([]string, 0, len(addrs))
+	for _, s := range addrs {
+		if strings.ContainsAny(s, "\r\n") {
+			return "", errors.New("address contains a line break")
+		}
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return "", fmt.Errorf("%q: %w", s, err)
+		}
+		out = append(out, addr.String())
+	}
+	return strings.Join(out, ", "), nil
+}
+
+func messageIDDomain(from string) string {
+	addr := from
+	if a, err := mail.ParseAddress(from); err == nil {
+		addr = a.Address
+	}
+	if at := strings.LastIndex(addr, "@"); at != -1 && at < len(addr)-1 {
+		return addr[at+1:]
+	}
+	return "localhost"
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,202 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+// file names looked up inside each template directory
+const (
+	SubjectFile = "subject.txt"
+	HTMLFile    = "body.html"
+	TextFile    = "body.txt"
+)
+
+// Email holds the locally rendered parts of an email.
+type Email struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Renderer renders emails locally from Go templates. Stored templates live in
+// one directory per template ID containing subject.txt and body.html and/or
+// body.txt. A nil Renderer only renders inline templates.
+type Renderer struct {
+	fsys fs.FS
+
+	mu    sync.RWMutex
+	cache map[string]*templateSet
+}
+
+type templateSet struct {
+	subject *texttemplate.Template
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+}
+
+// NewRenderer creates a renderer that loads stored templates from fsys.
+func NewRenderer(fsys fs.FS) *Renderer {
+	return &Renderer{
+		fsys:  fsys,
+		cache: make(map[string]*templateSet),
+	}
+}
+
+// NewDirRenderer creates a renderer that loads stored templates from a
+// directory, such as a templates folder shipped in the Lambda bundle.
+func NewDirRenderer(dir string) (*Renderer, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open templates directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("templates path is not a directory: %s", dir)
+	}
+	return NewRenderer(os.DirFS(dir)), nil
+}
+
+// Render renders the email described by the provider data with its template
+// data. Templates stored under pd.TemplateID are used when present, and inline
+// subject/html/text in the provider data override them part by part.
+func (r *Renderer) Render(pd *types.EmailProviderData) (*Email, error) {
+	set := &templateSet{}
+
+	if r != nil && pd.TemplateID != "" {
+		stored, err := r.load(pd.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		*set = *stored
+	}
+
+	var err error
+	if pd.Subject != "" {
+		if set.subject, err = texttemplate.New(SubjectFile).Option("missingkey=zero").Parse(pd.Subject); err != nil {
+			return nil, fmt.Errorf("failed to parse subject template: %w", err)
+		}
+	}
+	if pd.HTML != "" {
+		if set.html, err = htmltemplate.New(HTMLFile).Option("missingkey=zero").Parse(pd.HTML); err != nil {
+			return nil, fmt.Errorf("failed to parse html template: %w", err)
+		}
+	}
+	if pd.Text != "" {
+		if set.text, err = texttemplate.New(TextFile).Option("missingkey=zero").Parse(pd.Text); err != nil {
+			return nil, fmt.Errorf("failed to parse text template: %w", err)
+		}
+	}
+
+	if set.subject == nil {
+		return nil, errors.New("subject template missing")
+	}
+	if set.html == nil && set.text == nil {
+		return nil, errors.New("html or text template required")
+	}
+
+	return set.execute(pd.TemplateData)
+}
+
+// load parses and caches the stored templates for a template ID.
+func (r *Renderer) load(id string) (*templateSet, error) {
+	r.mu.RLock()
+	set, ok := r.cache[id]
+	r.mu.RUnlock()
+	if ok {
+		return set, nil
+	}
+
+	if !fs.ValidPath(id) {
+		return nil, fmt.Errorf("invalid template id: %s", id)
+	}
+
+	set = &templateSet{}
+
+	subject, err := r.readOptional(path.Join(id, SubjectFile))
+	if err != nil {
+		return nil, err
+	}
+	if subject != nil {
+		if set.subject, err = texttemplate.New(SubjectFile).Option("missingkey=zero").Parse(string(bytes.TrimSpace(subject))); err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s: %w", id, SubjectFile, err)
+		}
+	}
+
+	html, err := r.readOptional(path.Join(id, HTMLFile))
+	if err != nil {
+		return nil, err
+	}
+	if html != nil {
+		if set.html, err = htmltemplate.New(HTMLFile).Option("missingkey=zero").Parse(string(html)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s: %w", id, HTMLFile, err)
+		}
+	}
+
+	text, err := r.readOptional(path.Join(id, TextFile))
+	if err != nil {
+		return nil, err
+	}
+	if text != nil {
+		if set.text, err = texttemplate.New(TextFile).Option("missingkey=zero").Parse(string(text)); err != nil {
+			return nil, fmt.Errorf("failed to parse %s/%s: %w", id, TextFile, err)
+		}
+	}
+
+	if set.subject == nil && set.html == nil && set.text == nil {
+		return nil, fmt.Errorf("template not found: %s", id)
+	}
+
+	r.mu.Lock()
+	r.cache[id] = set
+	r.mu.Unlock()
+
+	return set, nil
+}
+
+func (r *Renderer) readOptional(name string) ([]byte, error) {
+	b, err := fs.ReadFile(r.fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	return b, nil
+}
+
+func (s *templateSet) execute(data map[string]any) (*Email, error) {
+	var out Email
+	var buf bytes.Buffer
+
+	if err := s.subject.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject template: %w", err)
+	}
+	out.Subject = buf.String()
+
+	if s.html != nil {
+		buf.Reset()
+		if err := s.html.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render html template: %w", err)
+		}
+		out.HTML = buf.String()
+	}
+
+	if s.text != nil {
+		buf.Reset()
+		if err := s.text.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render text template: %w", err)
+		}
+		out.Text = buf.String()
+	}
+
+	return &out, nil
+}
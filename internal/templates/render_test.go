@@ -0,0 +1,203 @@
+package templates
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/types"
+)
+
+func newTestRenderer() *Renderer {
+	return NewRenderer(fstest.MapFS{
+		"welcome/subject.txt":   {Data: []byte("Welcome to {{.appName}}\n")},
+		"welcome/body.html":     {Data: []byte("<p>Your code is <b>{{.code}}</b></p>")},
+		"welcome/body.txt":      {Data: []byte("Your code is {{.code}}")},
+		"html-only/subject.txt": {Data: []byte("Code")},
+		"html-only/body.html":   {Data: []byte("<p>{{.name}}</p>")},
+	})
+}
+
+func TestRenderer_StoredTemplates(t *testing.T) {
+	r := newTestRenderer()
+
+	email, err := r.Render(&types.EmailProviderData{
+		TemplateID:   "welcome",
+		TemplateData: map[string]any{"appName": "ACME", "code": "123456"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if email.Subject != "Welcome to ACME" {
+		t.Errorf("expected trimmed subject 'Welcome to ACME', got %q", email.Subject)
+	}
+	if email.HTML != "<p>Your code is <b>123456</b></p>" {
+		t.Errorf("unexpected html: %q", email.HTML)
+	}
+	if email.Text != "Your code is 123456" {
+		t.Errorf("unexpected text: %q", email.Text)
+	}
+}
+
+func TestRenderer_InlineOverridesStored(t *testing.T) {
+	r := newTestRenderer()
+
+	email, err := r.Render(&types.EmailProviderData{
+		TemplateID:   "welcome",
+		TemplateData: map[string]any{"appName": "ACME", "code": "123456"},
+		Subject:      "{{.appName}} code",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if email.Subject != "ACME code" {
+		t.Errorf("expected inline subject, got %q", email.Subject)
+	}
+	if email.Text != "Your code is 123456" {
+		t.Errorf("expected stored text body, got %q", email.Text)
+	}
+}
+
+func TestRenderer_HTMLEscapesData(t *testing.T) {
+	r := newTestRenderer()
+
+	email, err := r.Render(&types.EmailProviderData{
+		TemplateID:   "html-only",
+		TemplateData: map[string]any{"name": "<script>"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(email.HTML, "<script>") {
+		t.Errorf("expected html to be escaped, got %q", email.HTML)
+	}
+	if email.Text != "" {
+		t.Errorf("expected empty text body, got %q", email.Text)
+	}
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	r := newTestRenderer()
+
+	testCases := []struct {
+		name string
+		pd   *types.EmailProviderData
+	}{
+		{"unknown template", &types.EmailProviderData{TemplateID: "missing"}},
+		{"invalid template id", &types.EmailProviderData{TemplateID: "../welcome"}},
+		{"inline without subject", &types.EmailProviderData{Text: "body"}},
+		{"inline without body", &types.EmailProviderData{Subject: "subject"}},
+		{"inline parse error", &types.EmailProviderData{Subject: "{{", Text: "body"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := r.Render(tc.pd); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRenderer_NilRendersInline(t *testing.T) {
+	var r *Renderer
+
+	email, err := r.Render(&types.EmailProviderData{
+		TemplateID:   "ignored",
+		TemplateData: map[string]any{"code": "123456"},
+		Subject:      "Code",
+		Text:         "{{.code}}",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if email.Text != "123456" {
+		t.Errorf("expected inline text, got %q", email.Text)
+	}
+}
+
+func TestBuildMIMEMessage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		email       *Email
+		contentType string
+	}{
+		{"text only", &Email{Subject: "Hi", Text: "body"}, "text/plain"},
+		{"html only", &Email{Subject: "Hi", HTML: "<p>body</p>"}, "text/html"},
+		{"alternative", &Email{Subject: "Hi", Text: "body", HTML: "<p>body</p>"}, "multipart/alternative"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := BuildMIMEMessage(Header{
+				From:    "ACME <noreply@example.com>",
+				To:      []string{"user@example.com"},
+				ReplyTo: []string{"support@example.com"},
+			}, tc.email)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+			if err != nil {
+				t.Fatalf("failed to parse message: %v", err)
+			}
+			if !strings.HasPrefix(msg.Header.Get("Content-Type"), tc.contentType) {
+				t.Errorf("expected content type %s, got %s", tc.contentType, msg.Header.Get("Content-Type"))
+			}
+			replyTo, err := msg.Header.AddressList("Reply-To")
+			if err != nil || len(replyTo) != 1 || replyTo[0].Address != "support@example.com" {
+				t.Errorf("expected reply-to header, got %s", msg.Header.Get("Reply-To"))
+			}
+			if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+				t.Errorf("expected message id on sender domain, got %s", msg.Header.Get("Message-ID"))
+			}
+		})
+	}
+}
+
+func TestBuildMIMEMessage_EncodesDisplayNames(t *testing.T) {
+	raw, err := BuildMIMEMessage(Header{
+		From: "Société Générale <noreply@example.com>",
+		To:   []string{"user@example.com"},
+	}, &Email{Subject: "Hi", Text: "body"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("failed to parse message: %v", err)
+	}
+	if !strings.HasPrefix(msg.Header.Get("From"), "=?utf-8?") {
+		t.Errorf("expected rfc 2047 encoded display name, got %s", msg.Header.Get("From"))
+	}
+	from, err := msg.Header.AddressList("From")
+	if err != nil || from[0].Name != "Société Générale" || from[0].Address != "noreply@example.com" {
+		t.Errorf("expected from address to round-trip, got %v (%v)", from, err)
+	}
+}
+
+func TestBuildMIMEMessage_RejectsHeaderInjection(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header Header
+	}{
+		{"from", Header{From: "ACME <noreply@example.com>\r\nBcc: victim@example.com", To: []string{"user@example.com"}}},
+		{"to", Header{From: "noreply@example.com", To: []string{"user@example.com\nBcc: victim@example.com"}}},
+		{"cc", Header{From: "noreply@example.com", To: []string{"user@example.com"}, Cc: []string{"a@example.com\r"}}},
+		{"reply-to", Header{From: "noreply@example.com", To: []string{"user@example.com"}, ReplyTo: []string{"Support\r\n <support@example.com>"}}},
+		{"invalid address", Header{From: "noreply@example.com", To: []string{"not an address"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := BuildMIMEMessage(tc.header, &Email{Subject: "Hi", Text: "body"}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Package tracing sets up OpenTelemetry tracing and provides helpers to trace
+// the stages of a send.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/cruxstack/cognito-custom-message-sender-go"
+
+// Provider flushes and shuts down the configured tracer provider. Lambda
+// freezes the process between invocations, so spans must be flushed before
+// each invocation returns.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// Setup installs the global tracer provider for the configured exporter:
+// "otlp" exports over OTLP/HTTP (configured with the standard OTEL_EXPORTER_OTLP_*
+// variables) and "stdout" writes spans to stdout. Without an exporter the
+// global no-op provider is kept and the returned Provider does nothing.
+func Setup(ctx context.Context, cfg *config.Config) (*Provider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.AppTracingExporter {
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "none", "":
+		return &Provider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.AppTracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.AppTracingExporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	return &Provider{tp: tp}, nil
+}
+
+// Flush exports all finished spans.
+func (p *Provider) Flush(ctx context.Context) error {
+	if p == nil || p.tp == nil {
+		return nil
+	}
+	return p.tp.ForceFlush(ctx)
+}
+
+// Shutdown flushes and stops the tracer provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil || p.tp == nil {
+		return nil
+	}
+	return p.tp.Shutdown(ctx)
+}
+
+// Start starts a span from the global tracer provider.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartInvocation starts the root span of a Lambda invocation, tagged with
+// the Lambda request ID so traces can be found from the invocation logs.
+func StartInvocation(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		attrs = append(attrs, attribute.String("faas.invocation_id", lc.AwsRequestID))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := Setup(ctx, &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Flush(ctx); err != nil {
+		t.Errorf("expected no-op flush, got: %v", err)
+	}
+
+	if _, err := Setup(ctx, &config.Config{AppTracingExporter: "zipkin"}); err == nil {
+		t.Error("expected error for unknown exporter")
+	}
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "req-123"})
+	ctx, root := StartInvocation(ctx, "HandleEvent")
+	_, child := Start(ctx, "encryption.Decrypt")
+	End(child, errors.New("access denied"))
+	End(root, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	decrypt, handle := spans[0], spans[1]
+	if decrypt.Parent().SpanID() != handle.SpanContext().SpanID() {
+		t.Error("expected decrypt span to be a child of the invocation span")
+	}
+	if decrypt.Status().Code != codes.Error || len(decrypt.Events()) != 1 {
+		t.Errorf("expected error to be recorded, got status %v", decrypt.Status())
+	}
+
+	var invocationID string
+	for _, a := range handle.Attributes() {
+		if a.Key == "faas.invocation_id" {
+			invocationID = a.Value.AsString()
+		}
+	}
+	if invocationID != "req-123" {
+		t.Errorf("expected lambda request id on invocation span, got %q", invocationID)
+	}
+}
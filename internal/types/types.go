@@ -1,20 +1,53 @@
 package types
 
 type EmailData struct {
-	DestinationAddress string            `json:"dstAddress"`
-	SourceAddress      string            `json:"srcAddress"`
-	Providers          *EmailProviderMap `json:"providers,omitempty"`
-	TemplateID         string            `json:"templateID"`
-	TemplateData       map[string]any    `json:"templateData"`
-	VerificationCode   string            `json:"-"`
+	DestinationAddress   string            `json:"dstAddress"`
+	DestinationAddresses []string          `json:"dstAddresses,omitempty"` // additional To recipients
+	CcAddresses          []string          `json:"ccAddresses,omitempty"`
+	BccAddresses         []string          `json:"bccAddresses,omitempty"`
+	SourceAddress        string            `json:"srcAddress"`
+	ReplyToAddresses     []string          `json:"replyToAddresses,omitempty"`
+	Providers            *EmailProviderMap `json:"providers,omitempty"`
+	ProviderOrder        []string          `json:"providerOrder,omitempty"` // overrides the configured provider chain
+	TemplateID           string            `json:"templateID"`
+	TemplateData         map[string]any    `json:"templateData"`
+	VerificationCode     string            `json:"-"`
 }
 
 type EmailProviderMap struct {
 	SendGrid *EmailProviderData `json:"sendgrid,omitempty"`
 	SES      *EmailProviderData `json:"ses,omitempty"`
+	SMTP     *EmailProviderData `json:"smtp,omitempty"`
 }
 
+// RenderingLocal renders the email with internal/templates instead of the
+// provider's server-side templates.
+const RenderingLocal = "local"
+
 type EmailProviderData struct {
 	TemplateID   string         `json:"templateId"`
 	TemplateData map[string]any `json:"templateData"`
+
+	// Rendering selects where templates are rendered; "local" sends raw MIME
+	// rendered from stored or inline templates. smtp always renders locally.
+	Rendering string `json:"rendering,omitempty"`
+
+	// ConfigurationSet, Tags and FeedbackForwardingAddress are only used by ses
+	ConfigurationSet          string            `json:"configurationSet,omitempty"`
+	Tags                      map[string]string `json:"tags,omitempty"`
+	FeedbackForwardingAddress string            `json:"feedbackForwardingAddress,omitempty"`
+
+	// Subject, HTML and Text are inline Go templates rendered locally. They
+	// override the matching stored template of TemplateID when set.
+	Subject string `json:"subject,omitempty"`
+	HTML    string `json:"html,omitempty"`
+	Text    string `json:"text,omitempty"`
+}
+
+type SMSData struct {
+	DestinationPhoneNumber string `json:"dstPhoneNumber"`
+	SourcePhoneNumber      string `json:"srcPhoneNumber,omitempty"`
+	SenderID               string `json:"senderId,omitempty"`
+	Message                string `json:"message"`
+	VerificationCode       string `json:"-"`
 }
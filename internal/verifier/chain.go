@@ -0,0 +1,73 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ChainStep is a named verifier in a ChainVerifier.
+type ChainStep struct {
+	Name     string
+	Verifier EmailVerifier
+}
+
+// ChainVerifier runs verifiers in order, typically from cheapest to most
+// expensive. An invalid result is definitive and stops the chain; otherwise
+// the result of the last verifier that returned one is used, with the
+// disposable and role-based flags of earlier verifiers carried over. A
+// verifier error is logged and the chain moves on to the next verifier; only
+// when every verifier fails are their errors returned, wrapped with each
+// verifier's name.
+type ChainVerifier struct {
+	Steps []ChainStep
+}
+
+func NewChainVerifier(steps ...ChainStep) *ChainVerifier {
+	return &ChainVerifier{Steps: steps}
+}
+
+func (v *ChainVerifier) VerifyEmail(ctx context.Context, email string) (*EmailVerificationResult, error) {
+	var result *EmailVerificationResult
+	var disposable, role bool
+	var errs []error
+
+	for _, step := range v.Steps {
+		res, err := step.Verifier.VerifyEmail(ctx, email)
+		if err != nil {
+			err = fmt.Errorf("%s verifier: %w", step.Name, err)
+			slog.WarnContext(ctx, "email verifier failed, trying next verifier", "verifier", step.Name, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+
+		disposable = disposable || res.IsDisposable
+		role = role || res.IsRoleBased
+		result = res
+
+		if !res.IsValid {
+			break
+		}
+	}
+
+	if result == nil {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("no email verifiers configured")
+	}
+
+	result.IsDisposable = disposable
+	result.IsRoleBased = role
+	return result, nil
+}
+
+// UnknownResult stands in for the result of a failed verification when the
+// failure mode is open. It counts as valid so the policy decides, and sets
+// unknown so policies can tell it apart from a real result.
+func UnknownResult(err error) *EmailVerificationResult {
+	raw, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return &EmailVerificationResult{IsValid: true, IsUnknown: true, Raw: string(raw)}
+}
@@ -0,0 +1,208 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+)
+
+// Scores of the dns verifier. A domain without MX records can still receive
+// mail on its A/AAAA address (RFC 5321 implicit MX), but that is rare for real
+// mailbox providers, so it scores lower.
+const (
+	dnsScoreMX       float32 = 100.0
+	dnsScoreImplicit float32 = 70.0
+)
+
+// Resolver is the subset of net.Resolver used by DNSEmailVerifier.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// DNSEmailVerifier checks that the domain of an email address can receive
+// mail. It resolves MX records, falling back to A/AAAA records, and treats
+// null MX (RFC 7505) and nonexistent domains as invalid. Definitive results
+// are cached per domain; lookup failures are returned as errors. Like the
+// offline verifier, it flags disposable domains and role-based local parts.
+type DNSEmailVerifier struct {
+	Resolver  Resolver
+	Timeout   time.Duration
+	CacheTTL  time.Duration
+	Whitelist []string
+
+	mu    sync.Mutex
+	cache map[string]dnsCacheEntry
+	now   func() time.Time
+}
+
+type dnsCacheEntry struct {
+	result  dnsDomainResult
+	expires time.Time
+}
+
+// dnsDomainResult is the outcome of the lookups for one domain. It is also the
+// Raw payload of the verification result.
+type dnsDomainResult struct {
+	Domain string   `json:"domain"`
+	MX     []string `json:"mx,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	Valid  bool     `json:"valid"`
+	Score  float32  `json:"score"`
+}
+
+func NewDNSVerifier(cfg *config.Config) *DNSEmailVerifier {
+	return &DNSEmailVerifier{
+		Resolver:  newResolver(cfg.AppEmailVerificationDNSResolver),
+		Timeout:   cfg.AppEmailVerificationDNSTimeout,
+		CacheTTL:  cfg.AppEmailVerificationDNSCacheTTL,
+		Whitelist: cfg.AppEmailVerificationWhitelist,
+	}
+}
+
+// newResolver returns the system resolver, or one that sends every query to
+// addr ("host:port") when set.
+func newResolver(addr string) Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+func (v *DNSEmailVerifier) VerifyEmail(ctx context.Context, email string) (*EmailVerificationResult, error) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return invalidResult("invalid email format"), nil
+	}
+
+	at := strings.LastIndex(addr.Address, "@")
+	if at == -1 || at == len(addr.Address)-1 {
+		return invalidResult("missing domain"), nil
+	}
+
+	domain := strings.ToLower(strings.TrimSuffix(addr.Address[at+1:], "."))
+	if !strings.Contains(domain, ".") {
+		return invalidResult("invalid domain"), nil
+	}
+	if slices.Contains(v.Whitelist, domain) {
+		result := &EmailVerificationResult{Score: 100.0, IsValid: true, Raw: "{}"}
+		classifyAddress(result, addr.Address)
+		return result, nil
+	}
+
+	res, err := v.lookupDomain(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dns result: %w", err)
+	}
+
+	result := &EmailVerificationResult{
+		Score:   res.Score,
+		IsValid: res.Valid,
+		Raw:     string(raw),
+	}
+	classifyAddress(result, addr.Address)
+	return result, nil
+}
+
+func (v *DNSEmailVerifier) lookupDomain(ctx context.Context, domain string) (dnsDomainResult, error) {
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+
+	v.mu.Lock()
+	entry, ok := v.cache[domain]
+	v.mu.Unlock()
+	if ok && now().Before(entry.expires) {
+		return entry.result, nil
+	}
+
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+
+	res, err := v.resolve(ctx, domain)
+	if err != nil {
+		return dnsDomainResult{}, err
+	}
+
+	if v.CacheTTL > 0 {
+		v.mu.Lock()
+		if v.cache == nil {
+			v.cache = make(map[string]dnsCacheEntry)
+		}
+		v.cache[domain] = dnsCacheEntry{result: res, expires: now().Add(v.CacheTTL)}
+		v.mu.Unlock()
+	}
+
+	return res, nil
+}
+
+func (v *DNSEmailVerifier) resolve(ctx context.Context, domain string) (dnsDomainResult, error) {
+	res := dnsDomainResult{Domain: domain}
+
+	records, err := v.Resolver.LookupMX(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return res, fmt.Errorf("mx lookup error: %w", err)
+	}
+
+	for _, mx := range records {
+		res.MX = append(res.MX, mx.Host)
+	}
+
+	// a single "." exchange means the domain accepts no mail
+	if len(records) == 1 && (records[0].Host == "." || records[0].Host == "") {
+		res.Reason = "null mx"
+		return res, nil
+	}
+	if len(records) > 0 {
+		res.Valid, res.Score = true, dnsScoreMX
+		return res, nil
+	}
+
+	ips, err := v.Resolver.LookupIPAddr(ctx, domain)
+	if err != nil && !isNotFound(err) {
+		return res, fmt.Errorf("address lookup error: %w", err)
+	}
+	if len(ips) == 0 {
+		res.Reason = "no mail host"
+		return res, nil
+	}
+
+	res.Valid, res.Score = true, dnsScoreImplicit
+	return res, nil
+}
+
+// isNotFound reports whether err means the name has no records of the queried
+// type, as opposed to a failed lookup.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+func invalidResult(reason string) *EmailVerificationResult {
+	raw, _ := json.Marshal(map[string]string{"error": reason})
+	return &EmailVerificationResult{Raw: string(raw)}
+}
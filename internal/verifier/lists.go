@@ -0,0 +1,65 @@
+package verifier
+
+import (
+	_ "embed"
+	"strings"
+)
+
+var (
+	//go:embed lists/disposable_domains.txt
+	disposableDomainsList string
+
+	//go:embed lists/role_local_parts.txt
+	roleLocalPartsList string
+
+	disposableDomains = parseList(disposableDomainsList)
+	roleLocalParts    = parseList(roleLocalPartsList)
+)
+
+// parseList returns the lowercased, non-comment lines of an embedded list.
+func parseList(s string) map[string]bool {
+	out := make(map[string]bool)
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.ToLower(strings.TrimSpace(line))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out[line] = true
+	}
+	return out
+}
+
+// IsDisposableDomain reports whether domain, or a domain it is a subdomain
+// of, is a known disposable email domain.
+func IsDisposableDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot == -1 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	return false
+}
+
+// IsRoleLocalPart reports whether local is a role-based local part such as
+// admin or noreply. A "+tag" suffix is ignored.
+func IsRoleLocalPart(local string) bool {
+	local, _, _ = strings.Cut(strings.ToLower(local), "+")
+	return roleLocalParts[local]
+}
+
+// classifyAddress sets the disposable and role-based flags of r for address.
+// Flags already set by a verifier are kept.
+func classifyAddress(r *EmailVerificationResult, address string) {
+	at := strings.LastIndex(address, "@")
+	if r == nil || at == -1 {
+		return
+	}
+	r.IsDisposable = r.IsDisposable || IsDisposableDomain(address[at+1:])
+	r.IsRoleBased = r.IsRoleBased || IsRoleLocalPart(address[:at])
+}
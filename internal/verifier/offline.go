@@ -7,7 +7,8 @@ import (
 )
 
 // OfflineEmailVerifier performs basic email address validation without
-// external API calls. It validates the email format using RFC 5322 parsing.
+// external API calls. It validates the email format using RFC 5322 parsing
+// and flags disposable domains and role-based local parts from embedded lists.
 type OfflineEmailVerifier struct{}
 
 func (v *OfflineEmailVerifier) VerifyEmail(ctx context.Context, email string) (*EmailVerificationResult, error) {
@@ -45,13 +46,13 @@ func (v *OfflineEmailVerifier) VerifyEmail(ctx context.Context, email string) (*
 		}, nil
 	}
 
-	return &EmailVerificationResult{
-		Score:        100.0,
-		IsValid:      true,
-		IsDisposable: false,
-		IsRoleBased:  false,
-		Raw:          `{}`,
-	}, nil
+	result := &EmailVerificationResult{
+		Score:   100.0,
+		IsValid: true,
+		Raw:     `{}`,
+	}
+	classifyAddress(result, addr.Address)
+	return result, nil
 }
 
 func NewOfflineVerifier() *OfflineEmailVerifier {
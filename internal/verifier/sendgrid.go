@@ -14,12 +14,19 @@ import (
 )
 
 type SendGridEmailEmailAddressValidationCheckResult struct {
+	Domain struct {
+		IsSuspectedDisposableAddress bool `json:"is_suspected_disposable_address"`
+	} `json:"domain"`
+	LocalPart struct {
+		IsSuspectedRoleAddress bool `json:"is_suspected_role_address"`
+	} `json:"local_part"`
 }
 
 type SendGridEmailEmailAddressValidationResult struct {
-	Email   string  `json:"email"`
-	Verdict string  `json:"verdict"`
-	Score   float32 `json:"score"`
+	Email   string                                         `json:"email"`
+	Verdict string                                         `json:"verdict"`
+	Score   float32                                        `json:"score"`
+	Checks  SendGridEmailEmailAddressValidationCheckResult `json:"checks"`
 }
 
 type SendGridEmailEmailAddressValidationResponse struct {
@@ -32,13 +39,24 @@ type SendGridEmailVerifier struct {
 	APIKey    string
 }
 
+// VerifyEmail verifies email via the whitelist or the API. The embedded
+// disposable and role-based lists are applied on top of SendGrid's own checks.
 func (v *SendGridEmailVerifier) VerifyEmail(ctx context.Context, email string) (*EmailVerificationResult, error) {
 	result, _ := v.VerifyEmailViaWhitelist(ctx, email)
 	if result != nil {
 		slog.DebugContext(ctx, "email domain whitelisted", "email", email)
-		return result, nil
+	} else {
+		var err error
+		result, err = v.VerifyEmailViaAPI(ctx, email)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return v.VerifyEmailViaAPI(ctx, email)
+
+	if addr, err := mail.ParseAddress(email); err == nil {
+		classifyAddress(result, addr.Address)
+	}
+	return result, nil
 }
 
 func (v *SendGridEmailVerifier) VerifyEmailViaWhitelist(ctx context.Context, email string) (*EmailVerificationResult, error) {
@@ -96,9 +114,11 @@ func (v *SendGridEmailVerifier) VerifyEmailViaAPI(ctx context.Context, email str
 	result := payload.Result
 
 	return &EmailVerificationResult{
-		Score:   result.Score,
-		IsValid: result.Verdict != "Invalid",
-		Raw:     response.Body,
+		Score:        result.Score,
+		IsValid:      result.Verdict != "Invalid",
+		IsDisposable: result.Checks.Domain.IsSuspectedDisposableAddress,
+		IsRoleBased:  result.Checks.LocalPart.IsSuspectedRoleAddress,
+		Raw:          response.Body,
 	}, nil
 }
 
@@ -7,6 +7,7 @@ type EmailVerificationResult struct {
 	IsValid      bool    `json:"valid"`
 	IsDisposable bool    `json:"disposable"`
 	IsRoleBased  bool    `json:"role"`
+	IsUnknown    bool    `json:"unknown"`
 	Raw          string  `json:"raw"`
 }
 
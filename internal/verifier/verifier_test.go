@@ -3,9 +3,12 @@ package verifier
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestOfflineVerifier_ValidEmails(t *testing.T) {
@@ -71,6 +74,44 @@ func TestOfflineVerifier_InvalidEmails(t *testing.T) {
 	}
 }
 
+func TestOfflineVerifier_FlagsDisposableAndRoleAddresses(t *testing.T) {
+	v := NewOfflineVerifier()
+	ctx := context.Background()
+
+	testCases := []struct {
+		email        string
+		isDisposable bool
+		isRoleBased  bool
+	}{
+		{"user@example.com", false, false},
+		{"user@mailinator.com", true, false},
+		{"user@inbox.mailinator.com", true, false},
+		{"user@MAILINATOR.COM", true, false},
+		{"admin@example.com", false, true},
+		{"NoReply+alerts@example.com", false, true},
+		{"support@guerrillamail.com", true, true},
+		{"administrator.jones@example.com", false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.email, func(t *testing.T) {
+			result, err := v.VerifyEmail(ctx, tc.email)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.IsValid {
+				t.Errorf("expected email %q to be valid", tc.email)
+			}
+			if result.IsDisposable != tc.isDisposable {
+				t.Errorf("expected IsDisposable=%v, got %v", tc.isDisposable, result.IsDisposable)
+			}
+			if result.IsRoleBased != tc.isRoleBased {
+				t.Errorf("expected IsRoleBased=%v, got %v", tc.isRoleBased, result.IsRoleBased)
+			}
+		})
+	}
+}
+
 func TestSendGridVerifier_VerifyEmailViaAPI(t *testing.T) {
 	testCases := []struct {
 		name           string
@@ -160,6 +201,31 @@ func TestSendGridVerifier_VerifyEmailViaAPI(t *testing.T) {
 	}
 }
 
+func TestSendGridVerifier_VerifyEmail_Checks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result":{"email":"info@example.com","verdict":"Risky","score":0.4,` +
+			`"checks":{"domain":{"is_suspected_disposable_address":true},"local_part":{"is_suspected_role_address":false}}}}`))
+	}))
+	defer server.Close()
+
+	v := &SendGridEmailVerifier{
+		APIHost: server.URL,
+		APIKey:  "test-api-key",
+	}
+
+	result, err := v.VerifyEmail(context.Background(), "info@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsDisposable {
+		t.Error("expected sendgrid disposable check to be applied")
+	}
+	if !result.IsRoleBased {
+		t.Error("expected embedded role list to be applied")
+	}
+}
+
 func TestSendGridVerifier_VerifyEmailViaWhitelist(t *testing.T) {
 	v := &SendGridEmailVerifier{
 		Whitelist: []string{"trusted.com", "allowed.org"},
@@ -244,3 +310,238 @@ func TestSendGridVerifier_VerifyEmail_WhitelistSkipsAPI(t *testing.T) {
 		t.Error("API should be called for non-whitelisted domain")
 	}
 }
+
+// fakeResolver answers from static records and counts lookups
+type fakeResolver struct {
+	mx      map[string][]*net.MX
+	ips     map[string][]net.IPAddr
+	err     error
+	lookups int
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ips, ok := r.ips[host]; ok {
+		return ips, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com":    {{Host: "mx1.example.com.", Pref: 10}, {Host: "mx2.example.com.", Pref: 20}},
+			"nullmx.example": {{Host: ".", Pref: 0}},
+		},
+		ips: map[string][]net.IPAddr{
+			"implicit.example": {{IP: net.ParseIP("192.0.2.1")}},
+		},
+	}
+}
+
+func TestDNSVerifier_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		email         string
+		expectedValid bool
+		expectedScore float32
+	}{
+		{"user@example.com", true, 100.0},
+		{"user@EXAMPLE.com", true, 100.0},
+		{"user@implicit.example", true, 70.0},
+		{"user@nullmx.example", false, 0},
+		{"user@exmaple.com", false, 0},
+		{"not-an-email", false, 0},
+		{"user@localhost", false, 0},
+	}
+
+	v := &DNSEmailVerifier{Resolver: newFakeResolver()}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			result, err := v.VerifyEmail(context.Background(), tt.email)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.IsValid != tt.expectedValid {
+				t.Errorf("expected valid=%v, got %v: %s", tt.expectedValid, result.IsValid, result.Raw)
+			}
+			if result.Score != tt.expectedScore {
+				t.Errorf("expected score %v, got %v", tt.expectedScore, result.Score)
+			}
+		})
+	}
+}
+
+func TestDNSVerifier_NullMXReason(t *testing.T) {
+	v := &DNSEmailVerifier{Resolver: newFakeResolver()}
+
+	result, err := v.VerifyEmail(context.Background(), "user@nullmx.example")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(result.Raw), &raw); err != nil {
+		t.Fatalf("invalid raw result: %v", err)
+	}
+	if raw["reason"] != "null mx" {
+		t.Errorf("expected null mx reason, got %v", raw["reason"])
+	}
+}
+
+func TestDNSVerifier_CachesPerDomain(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	resolver := newFakeResolver()
+	v := &DNSEmailVerifier{Resolver: resolver, CacheTTL: time.Minute, now: func() time.Time { return now }}
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@Example.com"} {
+		if _, err := v.VerifyEmail(context.Background(), email); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if resolver.lookups != 1 {
+		t.Errorf("expected one lookup per domain, got %d", resolver.lookups)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := v.VerifyEmail(context.Background(), "a@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolver.lookups != 2 {
+		t.Errorf("expected expired entry to be looked up again, got %d lookups", resolver.lookups)
+	}
+}
+
+func TestDNSVerifier_LookupErrorsAreNotCached(t *testing.T) {
+	resolver := newFakeResolver()
+	resolver.err = &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}
+	v := &DNSEmailVerifier{Resolver: resolver, CacheTTL: time.Minute}
+
+	if _, err := v.VerifyEmail(context.Background(), "user@example.com"); err == nil {
+		t.Fatal("expected lookup error")
+	}
+
+	resolver.err = nil
+	result, err := v.VerifyEmail(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid {
+		t.Error("expected domain to be valid once the resolver recovers")
+	}
+}
+
+func TestDNSVerifier_WhitelistSkipsLookup(t *testing.T) {
+	resolver := newFakeResolver()
+	v := &DNSEmailVerifier{Resolver: resolver, Whitelist: []string{"internal.example"}}
+
+	result, err := v.VerifyEmail(context.Background(), "user@internal.example")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || resolver.lookups != 0 {
+		t.Errorf("expected whitelisted domain to be valid without lookups, got valid=%v lookups=%d", result.IsValid, resolver.lookups)
+	}
+}
+
+type stubVerifier struct {
+	result *EmailVerificationResult
+	err    error
+	calls  int
+}
+
+func (v *stubVerifier) VerifyEmail(ctx context.Context, email string) (*EmailVerificationResult, error) {
+	v.calls++
+	return v.result, v.err
+}
+
+func TestChainVerifier_ShortCircuitsOnInvalid(t *testing.T) {
+	dns := &stubVerifier{result: &EmailVerificationResult{Raw: `{"reason":"null mx"}`}}
+	api := &stubVerifier{result: &EmailVerificationResult{IsValid: true, Score: 0.9}}
+	v := NewChainVerifier(
+		ChainStep{Name: "offline", Verifier: NewOfflineVerifier()},
+		ChainStep{Name: "dns", Verifier: dns},
+		ChainStep{Name: "sendgrid", Verifier: api},
+	)
+
+	result, err := v.VerifyEmail(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsValid || result.Raw != `{"reason":"null mx"}` {
+		t.Errorf("expected dns result, got valid=%v raw=%s", result.IsValid, result.Raw)
+	}
+	if api.calls != 0 {
+		t.Errorf("expected sendgrid to be skipped, got %d calls", api.calls)
+	}
+}
+
+func TestChainVerifier_ReturnsLastResultWithFlags(t *testing.T) {
+	api := &stubVerifier{result: &EmailVerificationResult{IsValid: true, Score: 0.9, Raw: `{"verdict":"Valid"}`}}
+	v := NewChainVerifier(
+		ChainStep{Name: "offline", Verifier: NewOfflineVerifier()},
+		ChainStep{Name: "sendgrid", Verifier: api},
+	)
+
+	result, err := v.VerifyEmail(context.Background(), "admin@mailinator.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsValid || result.Score != 0.9 {
+		t.Errorf("expected sendgrid result, got valid=%v score=%v", result.IsValid, result.Score)
+	}
+	if !result.IsDisposable || !result.IsRoleBased {
+		t.Errorf("expected offline flags to carry over, got disposable=%v role=%v", result.IsDisposable, result.IsRoleBased)
+	}
+}
+
+func TestChainVerifier_ContinuesPastError(t *testing.T) {
+	dns := &stubVerifier{err: errors.New("i/o timeout")}
+	api := &stubVerifier{result: &EmailVerificationResult{Raw: `{"verdict":"Invalid"}`}}
+	v := NewChainVerifier(
+		ChainStep{Name: "dns", Verifier: dns},
+		ChainStep{Name: "sendgrid", Verifier: api},
+	)
+
+	result, err := v.VerifyEmail(context.Background(), "user@example.com")
+	if err != nil {
+		t.Fatalf("expected sendgrid result despite dns error, got %v", err)
+	}
+	if result.IsValid || result.Raw != `{"verdict":"Invalid"}` {
+		t.Errorf("expected invalid sendgrid result, got valid=%v raw=%s", result.IsValid, result.Raw)
+	}
+	if api.calls != 1 {
+		t.Errorf("expected sendgrid to be consulted, got %d calls", api.calls)
+	}
+}
+
+func TestChainVerifier_AllStepsFail(t *testing.T) {
+	v := NewChainVerifier(
+		ChainStep{Name: "dns", Verifier: &stubVerifier{err: errors.New("i/o timeout")}},
+		ChainStep{Name: "sendgrid", Verifier: &stubVerifier{err: errors.New("status 503")}},
+	)
+
+	_, err := v.VerifyEmail(context.Background(), "user@example.com")
+	if err == nil || err.Error() != "dns verifier: i/o timeout\nsendgrid verifier: status 503" {
+		t.Fatalf("expected both wrapped errors, got %v", err)
+	}
+}
+
+func TestUnknownResult(t *testing.T) {
+	result := UnknownResult(errors.New("sendgrid api returned status 503"))
+	if !result.IsValid || !result.IsUnknown || result.Score != 0 {
+		t.Errorf("unexpected unknown result: %+v", result)
+	}
+	if result.Raw != `{"error":"sendgrid api returned status 503"}` {
+		t.Errorf("unexpected raw: %s", result.Raw)
+	}
+}
@@ -7,27 +7,33 @@ import (
 	"os"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/cruxstack/cognito-custom-message-sender-go/internal/aws"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/config"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/logging"
 	"github.com/cruxstack/cognito-custom-message-sender-go/internal/sender"
+	"github.com/cruxstack/cognito-custom-message-sender-go/internal/tracing"
 )
 
 var (
-	s *sender.Sender
+	s  *sender.Sender
+	tp *tracing.Provider
 )
 
-func Handler(ctx context.Context, event aws.CognitoEventUserPoolsCustomEmailSender) error {
+// Handler accepts both Custom Email Sender and Custom SMS Sender events. The
+// raw payload is routed by trigger source since the two share a header shape.
+func Handler(ctx context.Context, event json.RawMessage) error {
 	if os.Getenv("APP_DEBUG_MODE") == "true" {
-		evtJson, err := json.Marshal(event)
-		if err != nil {
-			slog.ErrorContext(ctx, "failed to marshal event", "error", err)
-		}
-		slog.DebugContext(ctx, "received event", "event", string(evtJson))
+		slog.DebugContext(ctx, "received event", "event", string(event))
+	}
+
+	err := s.HandleEvent(ctx, event)
+
+	// lambda freezes the process once the handler returns
+	if flushErr := tp.Flush(ctx); flushErr != nil {
+		slog.WarnContext(ctx, "failed to flush traces", "error", flushErr)
 	}
 
-	err := s.SendEmail(ctx, event)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to send email", "error", err)
+		slog.ErrorContext(ctx, "failed to send message", "error", err)
 		return err
 	}
 
@@ -42,7 +48,13 @@ func main() {
 	}
 
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.AppLogLevel})
-	slog.SetDefault(slog.New(handler))
+	slog.SetDefault(slog.New(logging.NewHandler(handler, cfg)))
+
+	tp, err = tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		slog.Error("failed to initialize tracing", "error", err)
+		os.Exit(1)
+	}
 
 	s, err = sender.NewSender(context.Background(), cfg)
 	if err != nil {